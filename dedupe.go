@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	"example.com/m/v2/xlsx"
+)
+
+// rowDeduper drops rows whose cells repeat those of an earlier row: the same values in
+// the same columns, wherever the rows are. Only a 128-bit hash of each distinct row is
+// kept, so memory grows with the distinct rows rather than with the input.
+type rowDeduper struct {
+	seen    map[[16]byte]struct{}
+	removed int // Rows dropped so far
+}
+
+func newRowDeduper() *rowDeduper {
+	return &rowDeduper{seen: make(map[[16]byte]struct{})}
+}
+
+// filter drops the rows of data seen before, in this call or an earlier one. The cells of
+// a row must be next to each other, as ReadSheetData returns them.
+func (r *rowDeduper) filter(data []xlsx.CellData) []xlsx.CellData {
+	kept := data[:0]
+	var num [4]byte
+	var sum [16]byte
+	for start := 0; start < len(data); {
+		end := start + 1
+		for end < len(data) && data[end].RowNumber == data[start].RowNumber &&
+			data[end].SheetName == data[start].SheetName && data[end].SourceFile == data[start].SourceFile {
+			end++
+		}
+		h := fnv.New128a()
+		for _, d := range data[start:end] {
+			// The value's length keeps "ab","c" apart from "a","bc"
+			binary.LittleEndian.PutUint32(num[:], uint32(d.ColumnNumber))
+			h.Write(num[:])
+			binary.LittleEndian.PutUint32(num[:], uint32(len(d.SheetValue)))
+			h.Write(num[:])
+			h.Write([]byte(d.SheetValue))
+		}
+		h.Sum(sum[:0])
+		if _, dup := r.seen[sum]; dup {
+			r.removed++
+		} else {
+			r.seen[sum] = struct{}{}
+			kept = append(kept, data[start:end]...)
+		}
+		start = end
+	}
+	return kept
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseDelimiter validates the -delimiter flag: a single character, with "\t" accepted for tab
+func parseDelimiter(value string) (rune, error) {
+	if value == `\t` {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(value)
+	if size == 0 || size != len(value) {
+		return 0, fmt.Errorf("invalid delimiter %q: must be a single character", value)
+	}
+	if r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid delimiter %q", value)
+	}
+	return r, nil
+}
+
+// parseByteSize parses the -max-mem flag: a number of bytes with an optional KB, MB or GB
+// suffix (powers of 1024, case-insensitive), e.g. 512MB
+func parseByteSize(value string) (int64, error) {
+	number, unit := strings.ToUpper(strings.TrimSpace(value)), int64(1)
+	for _, suffix := range []struct {
+		name string
+		size int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(number, suffix.name) {
+			number, unit = strings.TrimSpace(strings.TrimSuffix(number, suffix.name)), suffix.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("invalid size %q: use a positive number of bytes, optionally with KB, MB or GB", value)
+	}
+	return n * unit, nil
+}
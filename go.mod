@@ -1,11 +1,17 @@
 module example.com/m/v2
 
-go 1.23
+go 1.23.0
 
-require github.com/parquet-go/parquet-go v0.23.0
+require (
+	github.com/linkedin/goavro/v2 v2.12.0
+	github.com/parquet-go/parquet-go v0.23.0
+	golang.org/x/net v0.43.0
+	golang.org/x/text v0.28.0
+)
 
 require (
 	github.com/andybalholm/brotli v1.1.0 // indirect
+	github.com/golang/snappy v0.0.1 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
@@ -13,5 +19,5 @@ require (
 	github.com/pierrec/lz4/v4 v4.1.21 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/segmentio/encoding v0.4.0 // indirect
-	golang.org/x/sys v0.23.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
 )
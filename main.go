@@ -1,16 +1,19 @@
 package main
 
 import (
-	"archive/zip"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"slices"
 	"strings"
-	"sync"
+
+	"example.com/m/v2/xlsx"
 )
 
 // Profiling setup and teardown
@@ -46,59 +49,701 @@ func stopProfiling(cpuFile, memFile *os.File) {
 	}
 }
 
+// setSourceFile records the input workbook on every row, keeping the SourceFile of rows
+// read back from an earlier export
+func setSourceFile(data []xlsx.CellData, fileName string) {
+	for i := range data {
+		if data[i].SourceFile == "" {
+			data[i].SourceFile = fileName
+		}
+	}
+}
+
+// readFilesConcurrently reads each input file on its own goroutine, at most
+// runtime.NumCPU() at a time, and calls handle with the results in input order
+func readFilesConcurrently(fileNames []string, opts xlsx.ReadOptions, withMetadata bool, handle func(fileName string, file *xlsx.File, err error)) {
+	type result struct {
+		file *xlsx.File
+		err  error
+	}
+	results := make([]chan result, len(fileNames))
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i, fileName := range fileNames {
+		results[i] = make(chan result, 1)
+		go func(fileName string, out chan<- result) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			file, err := xlsx.ReadFile(fileName, opts, withMetadata)
+			out <- result{file, err}
+		}(fileName, results[i])
+	}
+	for i, fileName := range fileNames {
+		res := <-results[i]
+		handle(fileName, res.file, res.err)
+	}
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run is the body of main, returning the process exit code so deferred cleanup still runs
+func run() (code int) {
 	// Parse command-line arguments
 	cpuProfile := flag.String("cpuprofile", "", "write CPU profile to `file`")
 	memProfile := flag.String("memprofile", "", "write memory profile to `file`")
+	expandMerged := flag.Bool("expand-merged", false, "copy each merged range's top-left value to every cell in the range")
+	flattenMerged := flag.Bool("flatten-merged", false, "copy each merged range's top-left value along the range's top row only, e.g. to name every column under a merged header")
+	convertDates := flag.Bool("dates", false, "convert date/time formatted numbers to ISO dates, times and datetimes")
+	coerceNumbers := flag.Bool("coerce-numbers", false, "write values such as $1,234.50 or (1 000) as plain numbers (1234.50, -1000), leaving other text as it is")
+	dense := flag.Bool("dense", false, "emit every cell of each sheet's used range, including empty ones")
+	progress := flag.Bool("progress", false, "report rows read per sheet on stderr")
+	progressEvery := flag.Int("progress-every", 100_000, "with -progress, report every `n` rows")
+	noHeader := flag.Bool("no-header", false, "do not write the CSV header row")
+	quoteAll := flag.Bool("quote-all", false, "quote every field in CSV output")
+	sanitize := flag.Bool("sanitize", false, "prefix CSV values starting with =, +, -, @ with ' to prevent formula injection")
+	delimiter := flag.String("delimiter", ",", "CSV field separator, a single `char`acter (\\t for tab)")
+	bom := flag.Bool("bom", false, "start CSV output with a UTF-8 byte order mark so Excel detects the encoding")
+	newline := flag.String("newline", "", "replace line breaks inside CSV values with `token`, e.g. \\n or a space")
+	crlf := flag.Bool("crlf", false, "end CSV lines with \\r\\n instead of \\n")
+	jsonBySheet := flag.Bool("json-by-sheet", false, "write JSON as an object mapping each sheet name to its cells")
+	styles := flag.Bool("styles", false, "also write each cell's style index in CSV and JSON output, and the style catalog (fonts, fills, borders) in the -metadata sidecar")
+	columnLetters := flag.Bool("column-letters", false, "also write each cell's column as letters (A, B, ..., AA) in CSV and JSON output")
+	pretty := flag.Bool("pretty", false, "indent JSON output")
+	jsonArrays := flag.Bool("json-arrays", false, "write JSON cells as rows, arrays of values ordered by column ([[\"A1\",\"B1\"],[\"A2\",\"B2\"]]), with \"\" for gaps")
+	jsonHeader := flag.Bool("json-header", false, "with -json-arrays, start each sheet's rows with its first row, the header, even when -filter drops it")
+	jsonMerges := flag.Bool("json-merges", false, "write JSON as {\"cells\": ..., \"merges\": [...]}, listing each sheet's merged ranges")
+	jsonNull := flag.Bool("json-null", false, "in JSON output, write cells added by -dense as null, keeping \"\" for cells that exist but are empty")
+	cellRange := flag.String("range", "", "only export cells in `range` (A1:D100, Sheet1!A1:D100, a defined name, or auto for each sheet's autofilter range)")
+	table := flag.String("table", "", "only export the Excel table (ListObject) called `name`, including its header row")
+	filterExpr := flag.String("filter", "", "only export rows matching `expr`: C=value, C!=value or C~text (contains)")
+	maxCols := flag.Int("max-cols", 0, "drop cells right of column `n` while reading, also limiting -dense (0 for no limit)")
+	sample := flag.Int("sample", 0, "only export every `n`th row of each sheet, starting with the first (0 for all rows)")
+	columnList := flag.String("columns", "", "only export the cells in these `columns`, e.g. A,C,F or A:C")
+	metadataPath := flag.String("metadata", "", "also write sheet metadata (layout, ...) as JSON to `file`")
+	schemaPath := flag.String("schema", "", "also write the type of each sheet column's values (number, string, boolean, date or error) as JSON to `file`; if it exists, read it instead and warn about values that do not fit its types")
+	activeOnly := flag.Bool("active-only", false, "only export the sheet that was active when the workbook was saved")
+	recoverZip := flag.Bool("recover", false, "read what is intact from damaged or truncated XLSX files, with a warning")
+	sheetWorkers := flag.Int("sheet-workers", 0, "decode sheets larger than 32MB of XML on `n` goroutines each (0 for one per CPU, 1 to disable)")
+	merge := flag.Bool("merge", false, "concatenate all input files into the single target file")
+	dedupe := flag.Bool("dedupe", false, "drop rows whose values repeat an earlier row of the same output file, e.g. from repeated sheets")
+	outputDir := flag.String("output-dir", "", "write one file per input into `dir`, named by -name-template; every argument is then an input file")
+	nameTemplate := flag.String("name-template", "", "name the files written into a directory after `template`: {file} is the input's name without extension, {sheet} the sheet name (one file per sheet), {ext} the format (default {file}.{ext})")
+	failOnWarnings := flag.Bool("fail-on-warnings", false, "exit with status 1 if any warning was reported, still writing the output (unlike -strict)")
+	strict := flag.Bool("strict", false, "fail a file that has warnings (e.g. duplicate cells) or sheets that cannot be read")
+	validate := flag.Bool("validate", false, "only read the files and report errors and warnings, without writing output")
+	head := flag.Int("head", 0, "only print the first `n` rows of each sheet as a table on stdout, without writing output")
+	tail := flag.Int("tail", 0, "only print the last `n` rows of each sheet as a table on stdout, without writing output")
+	showStats := flag.Bool("stats", false, "print a table of per-sheet parse statistics (bytes, XML tokens, cells, time) to stderr at the end")
+	verbose := flag.Bool("verbose", false, "log debug detail to stderr")
+	quiet := flag.Bool("quiet", false, "only log warnings and errors to stderr")
+	avroCodec := flag.String("avro-codec", "deflate", "Avro block compression `codec`: deflate, snappy or null")
+	stream := flag.Bool("stream", false, "with CSV output, write each row as soon as it is read, holding a single row in memory; merged-range flags are not set")
+	readBuffer := flag.String("read-buffer", "", "read XML parts through a buffer of `size` (e.g. 1MB, default 128KB); smaller parts use their own size")
+	maxMem := flag.String("max-mem", "", "with Parquet output, read one sheet at a time and flush a row group whenever the buffered cells reach `size` (e.g. 512MB)")
+	format := flag.String("format", "", "output `format` (csv, json, parquet, xlsx or avro), overriding the target's extension; default csv for - (stdout) and directories")
 	flag.Parse()
 
-	if flag.NArg() < 2 {
-		fmt.Println("Usage: go run main.go <xlsx_file> <targetFile>")
-		return
+	preview := *head != 0 || *tail != 0
+	if flag.NArg() < 2 && !((*validate || preview || *outputDir != "") && flag.NArg() == 1) {
+		fmt.Fprintln(os.Stderr, "Usage: go run main.go [flags] <xlsx_file>... <target>")
+		fmt.Fprintln(os.Stderr, "       go run main.go -format csv [flags] <xlsx_file> -")
+		fmt.Fprintln(os.Stderr, "       go run main.go -output-dir <dir> [flags] <xlsx_file>...")
+		fmt.Fprintln(os.Stderr, "       go run main.go -validate [flags] <xlsx_file>...")
+		fmt.Fprintln(os.Stderr, "       go run main.go -head <n> | -tail <n> [flags] <xlsx_file>...")
+		fmt.Fprintln(os.Stderr, "  With several input files the target is an output directory, unless -merge is set.")
+		return 2
+	}
+	if *verbose && *quiet {
+		fmt.Fprintln(os.Stderr, "-verbose and -quiet cannot be combined")
+		return 2
+	}
+	setupLogging(*verbose, *quiet)
+	// The workbook problems reported by the code path taken below, for -fail-on-warnings
+	var warnings int
+	if *failOnWarnings {
+		defer func() {
+			if code == 0 && warnings > 0 {
+				slog.Error("failing because of -fail-on-warnings", "warnings", warnings)
+				code = 1
+			}
+		}()
+	}
+
+	fileNames := flag.Args()[:flag.NArg()-1]
+	targetPath := flag.Arg(flag.NArg() - 1)
+	if preview {
+		switch {
+		case *head < 0 || *tail < 0:
+			fmt.Fprintln(os.Stderr, "-head and -tail must be 0 or more")
+			return 2
+		case *head > 0 && *tail > 0:
+			fmt.Fprintln(os.Stderr, "-head and -tail cannot be combined")
+			return 2
+		case *validate:
+			fmt.Fprintln(os.Stderr, "-head and -tail cannot be combined with -validate")
+			return 2
+		case *expandMerged || *flattenMerged || *dense || *cellRange == xlsx.AutoFilterRange:
+			// The rows are streamed, as with -stream
+			fmt.Fprintf(os.Stderr, "-head and -tail cannot be combined with -expand-merged, -flatten-merged, -dense or -range %s\n", xlsx.AutoFilterRange)
+			return 2
+		}
+	}
+	if *validate || preview {
+		if *schemaPath != "" {
+			fmt.Fprintln(os.Stderr, "-schema cannot be combined with -validate, -head or -tail")
+			return 2
+		}
+		fileNames, targetPath = flag.Args(), "" // No output, every argument is an input
+	}
+	if *outputDir != "" {
+		if *merge || *validate || preview {
+			fmt.Fprintln(os.Stderr, "-output-dir cannot be combined with -merge, -validate, -head or -tail")
+			return 2
+		}
+		fileNames, targetPath = flag.Args(), *outputDir
+	}
+	// Output goes into a directory, one file per input (or per sheet)
+	batch := *outputDir != "" || (len(fileNames) > 1 && !*merge && !*validate && !preview)
+	if *nameTemplate != "" && !batch {
+		fmt.Fprintln(os.Stderr, "-name-template only applies to several input files or -output-dir")
+		return 2
+	}
+	if targetPath == xlsx.StdoutTarget && len(fileNames) > 1 && !*merge {
+		fmt.Fprintln(os.Stderr, "several input files can only be written to stdout with -merge")
+		return 2
+	}
+	comma, err := parseDelimiter(*delimiter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *jsonHeader && !*jsonArrays {
+		fmt.Fprintln(os.Stderr, "-json-header only applies to -json-arrays")
+		return 2
+	}
+	if *table != "" && *cellRange != "" {
+		fmt.Fprintln(os.Stderr, "-table and -range cannot be combined")
+		return 2
+	}
+	var rowFilter *xlsx.RowFilter
+	if *filterExpr != "" {
+		if rowFilter, err = xlsx.ParseRowFilter(*filterExpr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	if _, err := xlsx.ParseAvroCodec(*avroCodec); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *maxCols < 0 || *maxCols > xlsx.MaxColumns {
+		fmt.Fprintf(os.Stderr, "invalid -max-cols %d: must be between 0 and %d\n", *maxCols, xlsx.MaxColumns)
+		return 2
+	}
+	if *sample < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -sample %d: must be 0 or more\n", *sample)
+		return 2
+	}
+	if *sample > 1 && *dense {
+		// The grid would bring back the skipped rows as empty ones
+		fmt.Fprintln(os.Stderr, "-sample cannot be combined with -dense")
+		return 2
+	}
+	if *sheetWorkers < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -sheet-workers %d: must be 0 or more\n", *sheetWorkers)
+		return 2
+	}
+	var maxMemBytes int64
+	if *maxMem != "" {
+		if maxMemBytes, err = parseByteSize(*maxMem); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		if *strict {
+			// Cells are written as they are read, before the file's warnings are known
+			fmt.Fprintln(os.Stderr, "-max-mem cannot be combined with -strict")
+			return 2
+		}
+	}
+	if *stream {
+		// These need a whole sheet, or a whole file's warnings, before anything is written
+		switch {
+		case *expandMerged || *flattenMerged || *dense:
+			fmt.Fprintln(os.Stderr, "-stream cannot be combined with -expand-merged, -flatten-merged or -dense")
+			return 2
+		case *cellRange == xlsx.AutoFilterRange:
+			fmt.Fprintf(os.Stderr, "-stream cannot be combined with -range %s\n", xlsx.AutoFilterRange)
+			return 2
+		case *dedupe || *strict || *maxMem != "":
+			fmt.Fprintln(os.Stderr, "-stream cannot be combined with -dedupe, -strict or -max-mem")
+			return 2
+		}
+	}
+	if *readBuffer != "" {
+		size, err := parseByteSize(*readBuffer)
+		if err != nil || size < xlsx.MinReadBufferSize || size > xlsx.MaxReadBufferSize {
+			fmt.Fprintf(os.Stderr, "invalid -read-buffer %q: use a size between 4KB and 64MB\n", *readBuffer)
+			return 2
+		}
+		xlsx.ReadBufferSize = int(size)
+	}
+	var columns map[int32]bool
+	if *columnList != "" {
+		if columns, err = xlsx.ParseColumns(*columnList); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	autoFilter := *cellRange == xlsx.AutoFilterRange
+	if autoFilter {
+		*cellRange = "" // Each sheet's own filter range, applied while reading the sheet
 	}
-	fileName := flag.Arg(0)
-	targetPath := flag.Arg(1)
 
 	// Profiling setup
 	cpuFile, memFile := setupProfiling(*cpuProfile, *memProfile)
 	defer stopProfiling(cpuFile, memFile)
 
-	// Open the XLSX file
-	r, err := zip.OpenReader(fileName)
+	opts := xlsx.ReadOptions{ExpandMerged: *expandMerged, FlattenMerged: *flattenMerged, Dense: *dense, ConvertDates: *convertDates, CoerceNumbers: *coerceNumbers, Range: *cellRange, Table: *table, AutoFilter: autoFilter, Filter: rowFilter, KeepHeaderRow: *jsonHeader, Columns: columns, ActiveOnly: *activeOnly, MaxColumns: int32(*maxCols), Sample: *sample, SheetWorkers: *sheetWorkers, Styles: *styles, Recover: *recoverZip, ProgressEvery: *progressEvery}
+	writeOpts := xlsx.WriteOptions{NoHeader: *noHeader, QuoteAll: *quoteAll, Sanitize: *sanitize, Delimiter: comma, CRLF: *crlf, BOM: *bom, NewlineReplacement: *newline, JSONBySheet: *jsonBySheet, Pretty: *pretty, JSONNull: *jsonNull, JSONMerges: *jsonMerges, JSONArrays: *jsonArrays, ColumnLetters: *columnLetters, StyleIDs: *styles, AvroCodec: *avroCodec}
+	if *progress {
+		opts.Progress = func(sheetName string, rows int) {
+			slog.Info("rows read", "sheet", sheetName, "rows", rows)
+		}
+	}
+
+	if *validate {
+		code, warnings = validateFiles(fileNames, opts, *strict)
+		return code
+	}
+	if preview {
+		code, warnings = previewFiles(fileNames, opts, *head, *tail)
+		return code
+	}
+
+	exitCode := 0
+	withMetadata := *metadataPath != ""
+	var metadata []*xlsx.WorkbookMetadata
+	var stats *statsTable
+	if *showStats {
+		stats = newStatsTable()
+		defer stats.print(os.Stderr)
+	}
+	var schema *xlsx.SchemaBuilder // Types of the cells written, for -schema
+	if *schemaPath != "" {
+		if _, err := os.Stat(*schemaPath); err == nil {
+			// The schema of an earlier run pins the column types, and is left as it is
+			pinned, err := xlsx.ReadSchema(*schemaPath)
+			if err != nil {
+				slog.Error("failed to read schema", "err", err)
+				return 1
+			}
+			schema = xlsx.NewSchemaBuilder(pinned)
+		} else {
+			schema = xlsx.NewSchemaBuilder(nil)
+			defer func() {
+				if err := xlsx.WriteSchema(schema.Schema(), *schemaPath); err != nil {
+					slog.Error("failed to write schema", "err", err)
+					code = 1
+				}
+			}()
+		}
+	}
+
+	// Single file, or several files merged into one target
+	if !batch {
+		// Determine the output format before spending time on reading
+		outputFormat, err := outputFormatFor(targetPath, *format)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		if maxMemBytes > 0 {
+			if outputFormat != "parquet" {
+				fmt.Fprintln(os.Stderr, "-max-mem only applies to Parquet output")
+				return 2
+			}
+			metadata, exitCode, warnings = convertSpilling(fileNames, targetPath, opts, withMetadata, maxMemBytes, len(fileNames) > 1, stats, schema, *dedupe)
+			return writeMetadataIfRequested(metadata, *metadataPath, exitCode)
+		}
+		if *stream {
+			if outputFormat != "csv" {
+				fmt.Fprintln(os.Stderr, "-stream only applies to CSV output")
+				return 2
+			}
+			metadata, exitCode, warnings = convertStreaming(fileNames, targetPath, opts, writeOpts, withMetadata, len(fileNames) > 1, stats, schema)
+			return writeMetadataIfRequested(metadata, *metadataPath, exitCode)
+		}
+		var data []xlsx.CellData
+		var deduper *rowDeduper
+		if *dedupe {
+			deduper = newRowDeduper() // Across all files, as they go to one target
+		}
+		readFilesConcurrently(fileNames, opts, withMetadata, func(fileName string, file *xlsx.File, err error) {
+			if err == nil {
+				file.Warnings = append(file.Warnings, schema.Check(file.Data)...)
+			}
+			ok, fileWarnings := reportFile(fileName, file, err, *strict)
+			warnings += fileWarnings
+			if !ok {
+				exitCode = 1
+				return
+			}
+			if len(fileNames) > 1 {
+				setSourceFile(file.Data, fileName)
+			}
+			if deduper != nil {
+				file.Data = deduper.filter(file.Data)
+			}
+			data = append(data, file.Data...)
+			if file.Metadata != nil {
+				metadata = append(metadata, file.Metadata)
+			}
+			stats.add(fileName, file.Stats)
+		})
+		if deduper != nil {
+			slog.Info("duplicate rows dropped", "rows", deduper.removed)
+		}
+
+		schema.Add(data)
+		if err := xlsx.WriteFile(data, targetPath, outputFormat, writeOpts); err != nil {
+			slog.Error("failed to write output", "err", err)
+			exitCode = 1
+		}
+		return writeMetadataIfRequested(metadata, *metadataPath, exitCode)
+	}
+
+	// Batch conversion: one output file per input in the target directory
+	batchFormat, err := outputFormatFor(xlsx.StdoutTarget, *format) // Same rules as stdout: -format or csv
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if maxMemBytes > 0 && batchFormat != "parquet" {
+		fmt.Fprintln(os.Stderr, "-max-mem only applies to Parquet output")
+		return 2
+	}
+	if *stream && batchFormat != "csv" {
+		fmt.Fprintln(os.Stderr, "-stream only applies to CSV output")
+		return 2
+	}
+	namer, err := newOutputNamer(targetPath, *nameTemplate, batchFormat, len(fileNames))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if maxMemBytes > 0 && namer.perSheet() {
+		// The spilling writer streams a whole file into one Parquet file
+		fmt.Fprintln(os.Stderr, "-max-mem cannot be combined with {sheet} in -name-template")
+		return 2
+	}
+	if *stream && namer.perSheet() {
+		fmt.Fprintln(os.Stderr, "-stream cannot be combined with {sheet} in -name-template")
+		return 2
+	}
+	if err := os.MkdirAll(targetPath, 0o755); err != nil {
+		slog.Error("failed to create output directory", "err", err)
+		return 1
+	}
+	tagSource := len(fileNames) > 1
+	if maxMemBytes > 0 {
+		for _, fileName := range fileNames {
+			outPath, err := namer.path(fileName, "")
+			if err != nil {
+				slog.Error("failed to write output", "file", fileName, "err", err)
+				exitCode = 1
+				continue
+			}
+			fileMetadata, fileExitCode, fileWarnings := convertSpilling([]string{fileName}, outPath, opts, withMetadata, maxMemBytes, tagSource, stats, schema, *dedupe)
+			metadata = append(metadata, fileMetadata...)
+			exitCode = max(exitCode, fileExitCode)
+			warnings += fileWarnings
+		}
+		return writeMetadataIfRequested(metadata, *metadataPath, exitCode)
+	}
+	if *stream {
+		for _, fileName := range fileNames {
+			outPath, err := namer.path(fileName, "")
+			if err != nil {
+				slog.Error("failed to write output", "file", fileName, "err", err)
+				exitCode = 1
+				continue
+			}
+			fileMetadata, fileExitCode, fileWarnings := convertStreaming([]string{fileName}, outPath, opts, writeOpts, withMetadata, tagSource, stats, schema)
+			metadata = append(metadata, fileMetadata...)
+			exitCode = max(exitCode, fileExitCode)
+			warnings += fileWarnings
+		}
+		return writeMetadataIfRequested(metadata, *metadataPath, exitCode)
+	}
+	readFilesConcurrently(fileNames, opts, withMetadata, func(fileName string, file *xlsx.File, err error) {
+		if err == nil {
+			file.Warnings = append(file.Warnings, schema.Check(file.Data)...)
+		}
+		ok, fileWarnings := reportFile(fileName, file, err, *strict)
+		warnings += fileWarnings
+		if !ok {
+			exitCode = 1
+			return
+		}
+		data := file.Data
+		if file.Metadata != nil {
+			metadata = append(metadata, file.Metadata)
+		}
+		stats.add(fileName, file.Stats)
+		if tagSource {
+			setSourceFile(data, fileName)
+		}
+		if *dedupe {
+			deduper := newRowDeduper()
+			data = deduper.filter(data)
+			slog.Info("duplicate rows dropped", "file", fileName, "rows", deduper.removed)
+		}
+		schema.Add(data)
+		if err := writeBatchOutput(data, fileName, namer, writeOpts); err != nil {
+			slog.Error("failed to write output", "file", fileName, "err", err)
+			exitCode = 1
+		}
+	})
+	return writeMetadataIfRequested(metadata, *metadataPath, exitCode)
+}
+
+// writeBatchOutput writes the cells of one input file into the output directory: to a
+// single file, or with {sheet} in the name template to one file per sheet, in tab order.
+// Sheets without cells get no file.
+func writeBatchOutput(data []xlsx.CellData, fileName string, namer *outputNamer, writeOpts xlsx.WriteOptions) error {
+	if !namer.perSheet() {
+		outPath, err := namer.path(fileName, "")
+		if err != nil {
+			return err
+		}
+		return xlsx.WriteFile(data, outPath, namer.format, writeOpts)
+	}
+	for _, cells := range xlsx.SplitSheets(data) {
+		sheet := cells[0].SheetName
+		if sheet == "" {
+			sheet = "Sheet1" // As the XLSX writer names it
+		}
+		outPath, err := namer.path(fileName, sheet)
+		if err != nil {
+			return err
+		}
+		if err := xlsx.WriteFile(cells, outPath, namer.format, writeOpts); err != nil {
+			return fmt.Errorf("sheet %s: %w", sheet, err)
+		}
+	}
+	return nil
+}
+
+// convertSpilling writes the files one after another to a single Parquet file through an
+// xlsx.ParquetSink limited to maxBytes, so memory is bounded by the largest sheet plus maxBytes
+// rather than by the total size of the input. tagSource sets SourceFile on every row.
+// The files' statistics are added to stats and the cells' types to schema, either of
+// which may be nil; cells not fitting a schema's pinned types are reported as warnings. With dedupe set, rows repeating an earlier row of the target are
+// dropped. It returns the files' metadata, the exit code and the number of warnings reported.
+func convertSpilling(fileNames []string, targetPath string, opts xlsx.ReadOptions, withMetadata bool, maxBytes int64, tagSource bool, stats *statsTable, schema *xlsx.SchemaBuilder, dedupe bool) ([]*xlsx.WorkbookMetadata, int, int) {
+	sink, err := xlsx.NewParquetSink(targetPath, maxBytes)
 	if err != nil {
-		fmt.Println("Failed to open file:", err)
-		return
+		slog.Error("failed to write output", "err", err)
+		return nil, 1, 0
+	}
+
+	exitCode, warnings := 0, 0
+	var metadata []*xlsx.WorkbookMetadata
+	var deduper *rowDeduper
+	if dedupe {
+		deduper = newRowDeduper()
+	}
+	for _, fileName := range fileNames {
+		var writeErr error
+		var mismatches []xlsx.Warning // Cells not fitting a pinned schema
+		file, err := xlsx.ReadFileSheets(fileName, opts, withMetadata, func(cells []xlsx.CellData) error {
+			if tagSource {
+				setSourceFile(cells, fileName)
+			}
+			if deduper != nil {
+				cells = deduper.filter(cells)
+			}
+			schema.Add(cells)
+			mismatches = append(mismatches, schema.Check(cells)...)
+			writeErr = sink.Write(cells)
+			return writeErr
+		})
+		if writeErr != nil {
+			slog.Error("failed to write output", "err", writeErr)
+			sink.Close()
+			return metadata, 1, warnings
+		}
+		if err == nil {
+			file.Warnings = append(file.Warnings, mismatches...)
+		}
+		ok, fileWarnings := reportFile(fileName, file, err, false)
+		warnings += fileWarnings
+		if !ok {
+			exitCode = 1
+			continue
+		}
+		if file.Metadata != nil {
+			metadata = append(metadata, file.Metadata)
+		}
+		stats.add(fileName, file.Stats)
+	}
+
+	if err := sink.Close(); err != nil {
+		slog.Error("failed to write output", "err", err)
+		exitCode = 1
 	}
-	defer r.Close()
+	if deduper != nil {
+		slog.Info("duplicate rows dropped", "path", targetPath, "rows", deduper.removed)
+	}
+	return metadata, exitCode, warnings
+}
 
-	// Read the workbook and shared strings
-	workbook, err := ReadWorkbook(r)
+// convertStreaming writes the files one after another to a single CSV file, each cell as
+// soon as its row is decoded, so memory stays constant however large the sheets are.
+// Merged ranges are listed after a sheet's cells, so the cells' Merged flags are not set.
+// tagSource sets SourceFile on every row, the files' statistics are added to stats and
+// the cells' types to schema, checking them against its pinned types. Like convertSpilling, it returns the metadata, the exit
+// code and the warnings count.
+func convertStreaming(fileNames []string, targetPath string, opts xlsx.ReadOptions, writeOpts xlsx.WriteOptions, withMetadata, tagSource bool, stats *statsTable, schema *xlsx.SchemaBuilder) ([]*xlsx.WorkbookMetadata, int, int) {
+	writeOpts.SourceColumn = tagSource
+	w, err := xlsx.NewRowWriter("csv", targetPath, writeOpts)
 	if err != nil {
-		fmt.Println("Failed to read workbook:", err)
-		return
+		slog.Error("failed to write output", "err", err)
+		return nil, 1, 0
+	}
+	if err := w.WriteHeader(); err != nil {
+		slog.Error("failed to write output", "err", err)
+		w.Close()
+		return nil, 1, 0
+	}
+
+	exitCode, warnings := 0, 0
+	var metadata []*xlsx.WorkbookMetadata
+	for _, fileName := range fileNames {
+		var writeErr error
+		var mismatches []xlsx.Warning // Cells not fitting a pinned schema
+		file, err := xlsx.ReadFileRows(fileName, opts, withMetadata, func(cells []xlsx.CellData) error {
+			schema.Add(cells)
+			mismatches = append(mismatches, schema.Check(cells)...)
+			for _, d := range cells {
+				if tagSource && d.SourceFile == "" {
+					d.SourceFile = fileName
+				}
+				if writeErr = w.WriteRow(d); writeErr != nil {
+					return writeErr
+				}
+			}
+			return nil
+		})
+		if writeErr != nil {
+			slog.Error("failed to write output", "err", writeErr)
+			w.Close()
+			return metadata, 1, warnings
+		}
+		if err == nil {
+			file.Warnings = append(file.Warnings, mismatches...)
+		}
+		ok, fileWarnings := reportFile(fileName, file, err, false)
+		warnings += fileWarnings
+		if !ok {
+			exitCode = 1
+			continue
+		}
+		if file.Metadata != nil {
+			metadata = append(metadata, file.Metadata)
+		}
+		stats.add(fileName, file.Stats)
+	}
+
+	if err := w.Close(); err != nil {
+		slog.Error("failed to write output", "err", err)
+		exitCode = 1
+	}
+	return metadata, exitCode, warnings
+}
+
+// outputFormatFor returns the format of a single target: the -format value when given,
+// otherwise the target's last extension, so archive.tar.csv is CSV and OUT.CSV is csv.
+// Stdout defaults to csv. Unknown formats are rejected before anything is read.
+func outputFormatFor(targetPath, format string) (string, error) {
+	if format == "" && targetPath != xlsx.StdoutTarget {
+		ext := strings.TrimPrefix(filepath.Ext(targetPath), ".")
+		if ext == "" {
+			return "", fmt.Errorf("cannot tell the output format of %s: add an extension such as .csv or use -format", targetPath)
+		}
+		format = ext
+	}
+	if format == "" {
+		format = "csv"
+	}
+	format = strings.ToLower(format)
+	if formats := xlsx.OutputFormats(); !slices.Contains(formats, format) {
+		return "", fmt.Errorf("unknown output format %q. Use %s", format, strings.Join(formats, ", "))
 	}
+	return format, nil
+}
 
-	sharedStrings, err := ReadSharedStrings(r)
+// reportFile logs the outcome of reading one input file and reports whether it is to be
+// converted: files that failed to open are not, nor, with strict set, files with warnings
+// or unreadable sheets. Otherwise those are logged and the rest of the file is converted.
+// It also returns the number of warnings it logged.
+func reportFile(fileName string, file *xlsx.File, err error, strict bool) (bool, int) {
 	if err != nil {
-		fmt.Println("Failed to read shared strings:", err)
-		return
-	}
-
-	// Process sheets concurrently
-	var data []CellData
-	var wg sync.WaitGroup
-	processSheetsConcurrently(r, workbook, sharedStrings, &data, &wg)
-
-	// Determine output format and write data
-	outputFormat := strings.Split(filepath.Base(targetPath), ".")[1]
-	switch outputFormat {
-	case "csv":
-		writeCSV(data, targetPath)
-	case "json":
-		writeJSON(data, targetPath)
-	case "parquet":
-		writeParquet(data, targetPath)
-	default:
-		fmt.Println("Unknown output format. Use 'csv', 'json', or 'parquet'.")
+		slog.Error("failed to read file", "file", fileName, "err", err)
+		return false, 0
+	}
+	warnings := len(file.Warnings)
+	if strict && (len(file.SheetErrors) > 0 || len(file.Warnings) > 0) {
+		slog.Error("file rejected by -strict", "file", fileName, "problems", len(file.SheetErrors)+len(file.Warnings))
+	}
+	for _, err := range file.SheetErrors {
+		var missing *xlsx.MissingSheetError
+		if errors.As(err, &missing) && !strict {
+			// The other sheets are still converted, and -metadata marks the sheet as missing
+			slog.Warn("sheet missing from archive", "file", fileName, "sheet", missing.Sheet, "part", missing.Path)
+			warnings++
+			continue
+		}
+		slog.Error("failed to read sheet", "file", fileName, "err", err)
+	}
+	for _, w := range file.Warnings {
+		slog.Warn("workbook problem", "file", fileName, "sheet", w.Sheet, "cell", w.Ref, "problem", w.Message)
+	}
+	if strict && (len(file.SheetErrors) > 0 || len(file.Warnings) > 0) {
+		return false, warnings
+	}
+	for _, s := range file.Stats {
+		slog.Debug("sheet read", "file", fileName, "sheet", s.Sheet, "bytes", s.Bytes, "tokens", s.Tokens, "rows", s.Rows, "cells", s.Cells, "duration", s.Duration)
+	}
+	slog.Debug("file read", "file", fileName, "cells", len(file.Data))
+	return true, warnings
+}
+
+// setupLogging sends diagnostics to stderr, keeping stdout free for data. The default
+// level shows progress and written files; -verbose adds debug detail and -quiet leaves
+// only warnings and errors.
+func setupLogging(verbose, quiet bool) {
+	level := slog.LevelInfo
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelWarn
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+}
+
+// writeMetadataIfRequested writes the -metadata sidecar when a path was given and
+// returns the exit code, turned into a failure if the sidecar cannot be written
+func writeMetadataIfRequested(metadata []*xlsx.WorkbookMetadata, metadataPath string, exitCode int) int {
+	if metadataPath == "" {
+		return exitCode
+	}
+	if err := xlsx.WriteMetadata(metadata, metadataPath); err != nil {
+		slog.Error("failed to write metadata", "err", err)
+		return 1
 	}
+	return exitCode
 }
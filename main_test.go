@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestOutputFormatFor(t *testing.T) {
+	tests := []struct {
+		target  string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{target: "out.csv", want: "csv"},
+		{target: "a.b.c.json", want: "json"},
+		{target: "noext", wantErr: true},
+		{target: "noext", format: "parquet", want: "parquet"},
+		{target: "OUT.CSV", want: "csv"},
+		{target: "dir.d/out", wantErr: true},
+		{target: "out.txt", wantErr: true},
+		{target: "out.txt", format: "JSON", want: "json"},
+		{target: "-", want: "csv"},
+		{target: "-", format: "avro", want: "avro"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.format, func(t *testing.T) {
+			got, err := outputFormatFor(tt.target, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("outputFormatFor(%q, %q) error = %v, want error %v", tt.target, tt.format, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("outputFormatFor(%q, %q) = %q, want %q", tt.target, tt.format, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultNameTemplate names each output file after its input, as in out_dir/report.csv
+const defaultNameTemplate = "{file}.{ext}"
+
+// templateField matches the placeholders of a -name-template
+var templateField = regexp.MustCompile(`\{[^{}]*\}`)
+
+// outputNamer builds the paths of the files written into an output directory from a
+// -name-template, and refuses to hand out the same path twice in one run
+type outputNamer struct {
+	dir      string
+	template string
+	format   string
+	used     map[string]string // Lower-cased path -> input file it was handed out for
+}
+
+// newOutputNamer checks the template: it may only use {file}, {sheet} and {ext}, and
+// with several input files it needs {file} to tell their outputs apart
+func newOutputNamer(dir, template, format string, inputs int) (*outputNamer, error) {
+	if template == "" {
+		template = defaultNameTemplate
+	}
+	for _, field := range templateField.FindAllString(template, -1) {
+		switch field {
+		case "{file}", "{sheet}", "{ext}":
+		default:
+			return nil, fmt.Errorf("invalid -name-template %q: unknown placeholder %s, use {file}, {sheet} and {ext}", template, field)
+		}
+	}
+	if inputs > 1 && !strings.Contains(template, "{file}") {
+		return nil, fmt.Errorf("invalid -name-template %q: several input files need {file} in the name", template)
+	}
+	return &outputNamer{dir: dir, template: template, format: format, used: make(map[string]string)}, nil
+}
+
+// perSheet reports whether each sheet is written to a file of its own
+func (n *outputNamer) perSheet() bool {
+	return strings.Contains(n.template, "{sheet}")
+}
+
+// path returns the output path for the input file and sheet, creating the directories
+// the template adds below dir. It fails when an earlier input or sheet got the same path,
+// e.g. two report.xlsx in different folders, rather than overwrite that output.
+func (n *outputNamer) path(fileName, sheet string) (string, error) {
+	base := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	name := strings.NewReplacer(
+		"{file}", sanitizeFileName(base),
+		"{sheet}", sanitizeFileName(sheet),
+		"{ext}", n.format,
+	).Replace(n.template)
+	outPath := filepath.Join(n.dir, name)
+
+	// Case-insensitive file systems would overwrite a name differing only in case
+	key := strings.ToLower(outPath)
+	if earlier, ok := n.used[key]; ok {
+		if earlier == fileName {
+			return "", fmt.Errorf("output file %s is named the same for two sheets of %s", outPath, fileName)
+		}
+		return "", fmt.Errorf("output file %s was already written for %s", outPath, earlier)
+	}
+	n.used[key] = fileName
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return "", err
+	}
+	return outPath, nil
+}
+
+// sanitizeFileName makes a file or sheet name safe to use as part of a file name on any
+// platform: path separators, characters Windows forbids and control characters become _,
+// and trailing dots and spaces, which Windows drops, are removed
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7F || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
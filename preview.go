@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"example.com/m/v2/xlsx"
+)
+
+// previewCellWidth is the widest a value is shown in a preview table, in characters
+const previewCellWidth = 30
+
+// previewRow is a row of a sheet assembled from its cells, values[i] being column first+i
+type previewRow struct {
+	number int32
+	first  int32
+	values []string
+}
+
+// sheetPreview keeps the rows of one sheet to print: the first head rows, or the last
+// tail rows in a ring buffer, so memory stays bounded by the rows shown
+type sheetPreview struct {
+	sheet string
+	head  int
+	tail  int
+	rows  []previewRow
+	next  int // With tail, the ring buffer slot the next row goes into
+	total int // Rows seen, including those not kept
+}
+
+// full reports whether -head has all its rows, so the rest of the sheet can be skipped
+func (p *sheetPreview) full() bool {
+	return p.head > 0 && len(p.rows) >= p.head
+}
+
+// add keeps the row if it is one of the rows to show
+func (p *sheetPreview) add(row previewRow) {
+	p.total++
+	switch {
+	case p.head > 0:
+		if len(p.rows) < p.head {
+			p.rows = append(p.rows, row)
+		}
+	case len(p.rows) < p.tail:
+		p.rows = append(p.rows, row)
+	default:
+		p.rows[p.next] = row
+		p.next = (p.next + 1) % p.tail
+	}
+}
+
+// ordered returns the kept rows in sheet order, unrolling the ring buffer
+func (p *sheetPreview) ordered() []previewRow {
+	return append(p.rows[p.next:len(p.rows):len(p.rows)], p.rows[:p.next]...)
+}
+
+// print writes the rows as a table aligned on columns, headed by the sheet's column letters
+func (p *sheetPreview) print(w io.Writer, fileName string) {
+	rows := p.ordered()
+	if p.head > 0 {
+		fmt.Fprintf(w, "==> %s [%s] first %d rows <==\n", fileName, p.sheet, len(rows))
+	} else {
+		fmt.Fprintf(w, "==> %s [%s] last %d of %d rows <==\n", fileName, p.sheet, len(rows), p.total)
+	}
+	firstCol, lastCol := int32(0), int32(0)
+	for _, row := range rows {
+		if firstCol == 0 || row.first < firstCol {
+			firstCol = row.first
+		}
+		lastCol = max(lastCol, row.first+int32(len(row.values))-1)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	line := []string{"ROW"}
+	for col := firstCol; col <= lastCol; col++ {
+		line = append(line, xlsx.IndexToColumn(col))
+	}
+	fmt.Fprintln(tw, strings.Join(line, "\t"))
+	for _, row := range rows {
+		line = append(line[:0], strconv.Itoa(int(row.number)))
+		for col := firstCol; col <= lastCol; col++ {
+			value := ""
+			if i := col - row.first; i >= 0 && int(i) < len(row.values) {
+				value = previewValue(row.values[i])
+			}
+			line = append(line, value)
+		}
+		fmt.Fprintln(tw, strings.Join(line, "\t"))
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+}
+
+// previewValue fits a value on one line of a preview table: line breaks and tabs become
+// spaces and long values are cut with an ellipsis
+func previewValue(value string) string {
+	value = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ", "\t", " ").Replace(value)
+	if runes := []rune(value); len(runes) > previewCellWidth {
+		return string(runes[:previewCellWidth-1]) + "…"
+	}
+	return value
+}
+
+// previewFiles prints the first head or the last tail rows of each sheet of every file
+// as a table on stdout, without writing output. The sheets are streamed, and with head
+// set the rest of a sheet is skipped once its rows are read. The files' warnings are
+// printed on stderr. It returns 1 if a file or sheet could not be read, and the number of
+// warnings printed.
+func previewFiles(fileNames []string, opts xlsx.ReadOptions, head, tail int) (int, int) {
+	exitCode, warnings := 0, 0
+	for _, fileName := range fileNames {
+		var preview *sheetPreview
+		var row previewRow
+		inRow := false
+		endRow := func() {
+			if inRow {
+				preview.add(row)
+				inRow = false
+			}
+		}
+		file, err := xlsx.ReadFileRows(fileName, opts, false, func(cells []xlsx.CellData) error {
+			for _, d := range cells {
+				if preview == nil || d.SheetName != preview.sheet {
+					endRow()
+					if preview != nil {
+						preview.print(os.Stdout, fileName)
+					}
+					preview = &sheetPreview{sheet: d.SheetName, head: head, tail: tail}
+				}
+				if inRow && d.RowNumber != row.number {
+					endRow()
+				}
+				if preview.full() || d.ColumnNumber < 1 {
+					continue
+				}
+				if !inRow {
+					row, inRow = previewRow{number: d.RowNumber, first: d.ColumnNumber}, true
+				}
+				if d.ColumnNumber < row.first {
+					// Cells of a row come in column order, except in damaged sheets: widen the row to the left
+					row.values = append(make([]string, row.first-d.ColumnNumber), row.values...)
+					row.first = d.ColumnNumber
+				}
+				for int(d.ColumnNumber-row.first) >= len(row.values) {
+					row.values = append(row.values, "")
+				}
+				row.values[d.ColumnNumber-row.first] = d.SheetValue
+			}
+			endRow()
+			if preview != nil && preview.full() {
+				return xlsx.SkipSheet
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: ERROR %v\n", fileName, err)
+			exitCode = 1
+			continue
+		}
+		if preview != nil {
+			preview.print(os.Stdout, fileName)
+		}
+		if file == nil {
+			continue
+		}
+		for _, err := range file.SheetErrors {
+			fmt.Fprintf(os.Stderr, "%s: ERROR %v\n", fileName, err)
+			exitCode = 1
+		}
+		for _, w := range file.Warnings {
+			fmt.Fprintf(os.Stderr, "%s: warning %s\n", fileName, w)
+		}
+		warnings += len(file.Warnings)
+	}
+	return exitCode, warnings
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"example.com/m/v2/xlsx"
+)
+
+// statsTable collects the sheet statistics of a conversion for -stats. Files are added
+// one at a time, as their results are handled.
+type statsTable struct {
+	start time.Time
+	rows  []statsRow
+}
+
+// statsRow is the statistics of one sheet of an input file
+type statsRow struct {
+	file string
+	xlsx.ParseStats
+}
+
+func newStatsTable() *statsTable {
+	return &statsTable{start: time.Now()}
+}
+
+// add records the sheets of an input file; it does nothing on a nil table
+func (t *statsTable) add(fileName string, stats []xlsx.ParseStats) {
+	if t == nil {
+		return
+	}
+	for _, s := range stats {
+		t.rows = append(t.rows, statsRow{file: fileName, ParseStats: s})
+	}
+}
+
+// print writes the table with a line per sheet and the totals. Sheets are read
+// concurrently, so their times add up to more than the wall time of the run.
+func (t *statsTable) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tSHEET\tROWS\tCELLS\tTOKENS\tMB\tTIME\tMB/S\t")
+	var total xlsx.ParseStats
+	for _, row := range t.rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", row.file, row.Sheet, statsLine(row.ParseStats))
+		total.Bytes += row.Bytes
+		total.Tokens += row.Tokens
+		total.Rows += row.Rows
+		total.Cells += row.Cells
+		total.Duration += row.Duration
+	}
+	fmt.Fprintf(tw, "total\t%d sheets\t%s\t\n", len(t.rows), statsLine(total))
+	tw.Flush()
+	fmt.Fprintf(w, "wall time %s\n", time.Since(t.start).Round(time.Millisecond))
+}
+
+// statsLine formats the counters of a table line, ending with its throughput
+func statsLine(s xlsx.ParseStats) string {
+	mb := float64(s.Bytes) / (1 << 20)
+	throughput := 0.0
+	if s.Duration > 0 {
+		throughput = mb / s.Duration.Seconds()
+	}
+	return fmt.Sprintf("%d\t%d\t%d\t%.1f\t%s\t%.1f", s.Rows, s.Cells, s.Tokens, mb, s.Duration.Round(time.Millisecond), throughput)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/m/v2/xlsx"
+)
+
+// validateFiles runs the full read path on every file without writing output and
+// prints a report of errors and warnings. It returns 1 if any file has errors, or with
+// strict set, warnings, and the number of warnings reported.
+func validateFiles(fileNames []string, opts xlsx.ReadOptions, strict bool) (int, int) {
+	var ok, withWarnings, failed, warnings int
+	readFilesConcurrently(fileNames, opts, false, func(fileName string, file *xlsx.File, err error) {
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("%s: ERROR %v\n", fileName, err)
+			return
+		case len(file.SheetErrors) > 0, strict && len(file.Warnings) > 0:
+			failed++
+			fmt.Printf("%s: %d errors, %d warnings\n", fileName, len(file.SheetErrors), len(file.Warnings))
+		case len(file.Warnings) > 0:
+			withWarnings++
+			fmt.Printf("%s: %d warnings\n", fileName, len(file.Warnings))
+		default:
+			ok++
+			fmt.Printf("%s: OK (%d cells)\n", fileName, len(file.Data))
+		}
+		for _, err := range file.SheetErrors {
+			fmt.Printf("  ERROR %v\n", err)
+		}
+		for _, w := range file.Warnings {
+			fmt.Printf("  warning %s\n", w)
+		}
+		warnings += len(file.Warnings)
+	})
+
+	fmt.Printf("Validated %d files: %d OK, %d with warnings, %d with errors\n", len(fileNames), ok, withWarnings, failed)
+	if failed > 0 {
+		return 1, warnings
+	}
+	return 0, warnings
+}
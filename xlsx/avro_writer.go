@@ -0,0 +1,126 @@
+package xlsx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// avroSchema describes a CellData record, with the field names used by the CSV header and
+// Parquet columns. SourceFile is optional, as in Parquet.
+const avroSchema = `{
+	"type": "record",
+	"name": "CellData",
+	"fields": [
+		{"name": "SheetName", "type": "string"},
+		{"name": "RowNumber", "type": "int"},
+		{"name": "ColumnNumber", "type": "int"},
+		{"name": "SheetValue", "type": "string"},
+		{"name": "Merged", "type": "boolean"},
+		{"name": "MergedRange", "type": "string"},
+		{"name": "SourceFile", "type": ["null", "string"], "default": null}
+	]
+}`
+
+// avroCodecs lists the block compression codecs accepted by -avro-codec
+var avroCodecs = []string{goavro.CompressionDeflateLabel, goavro.CompressionSnappyLabel, goavro.CompressionNullLabel}
+
+// avroBlockSize is the number of records written per Avro block
+const avroBlockSize = 10_000
+
+// ParseAvroCodec validates an Avro block compression codec, as given to -avro-codec
+func ParseAvroCodec(codec string) (string, error) {
+	if !slices.Contains(avroCodecs, codec) {
+		return "", fmt.Errorf("unknown Avro codec %q. Use deflate, snappy or null", codec)
+	}
+	return codec, nil
+}
+
+// avroWriter writes the cells as an Avro object container file, with the schema in the
+// file header and blocks compressed with opts.AvroCodec (deflate when empty)
+type avroWriter struct {
+	path     string
+	file     io.WriteCloser
+	buffered *bufio.Writer
+	writer   *goavro.OCFWriter
+	records  []interface{} // The next block
+	err      error         // First write error, returned again by Close
+}
+
+// newAvroWriter creates the Avro file at targetPath
+func newAvroWriter(targetPath string, opts WriteOptions) (RowWriter, error) {
+	file, err := createOutput(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Avro file: %w", err)
+	}
+
+	codec := opts.AvroCodec
+	if codec == "" {
+		codec = goavro.CompressionDeflateLabel
+	}
+	buffered := bufio.NewWriterSize(file, 128*1024)
+	writer, err := goavro.NewOCFWriter(goavro.OCFConfig{W: buffered, Schema: avroSchema, CompressionName: codec})
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error creating Avro writer: %w", err)
+	}
+	return &avroWriter{path: targetPath, file: file, buffered: buffered, writer: writer}, nil
+}
+
+func (w *avroWriter) WriteHeader() error { return nil } // NewOCFWriter already wrote the file header
+
+func (w *avroWriter) WriteRow(d CellData) error {
+	w.records = append(w.records, avroRecord(d))
+	if len(w.records) >= avroBlockSize {
+		w.flushBlock()
+	}
+	return w.err
+}
+
+// flushBlock appends the collected records as one block
+func (w *avroWriter) flushBlock() {
+	if w.err == nil && len(w.records) > 0 {
+		if err := w.writer.Append(w.records); err != nil {
+			w.err = fmt.Errorf("error writing data to Avro file: %w", err)
+		}
+	}
+	w.records = w.records[:0]
+}
+
+func (w *avroWriter) Close() error {
+	defer w.file.Close()
+
+	w.flushBlock()
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.buffered.Flush(); err != nil {
+		return fmt.Errorf("error writing Avro file: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("error closing Avro file: %w", err)
+	}
+	slog.Info("Avro output written", "path", w.path)
+	return nil
+}
+
+// avroRecord converts a cell to the generic form goavro encodes
+func avroRecord(d CellData) map[string]interface{} {
+	var sourceFile interface{} // null
+	if d.SourceFile != "" {
+		sourceFile = goavro.Union("string", d.SourceFile)
+	}
+	return map[string]interface{}{
+		"SheetName":    d.SheetName,
+		"RowNumber":    d.RowNumber,
+		"ColumnNumber": d.ColumnNumber,
+		"SheetValue":   d.SheetValue,
+		"Merged":       d.Merged,
+		"MergedRange":  d.MergedRange,
+		"SourceFile":   sourceFile,
+	}
+}
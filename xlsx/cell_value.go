@@ -0,0 +1,1272 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/big"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cell represents a single cell in a sheet
+type Cell struct {
+	R string `xml:"r,attr"` // Reference (e.g., "A1")
+	T string `xml:"t,attr"` // Type (e.g., "s" for shared string, "n" for number)
+	S string `xml:"s,attr"` // Style index into cellXfs, used to recognise dates
+	V string `xml:"v"`      // Value (content of the cell)
+}
+
+// SharedStrings represents shared strings in the workbook
+type SharedStrings struct {
+	Items []string `xml:"si>t"`
+}
+
+// Struct to hold cell data information
+type CellData struct {
+	SheetName    string   `json:"sheet_name"`
+	RowNumber    int32    `json:"row_number"`
+	ColumnNumber int32    `json:"column_number"`
+	SheetValue   string   `json:"sheet_value"`
+	Merged       bool     `json:"merged,omitempty"`
+	MergedRange  string   `json:"merged_range,omitempty"`
+	SourceFile   string   `json:"source_file,omitempty" parquet:",optional"` // Input workbook, set when converting several files
+	Type         CellType `json:"-" parquet:"-"`                             // Kind of value Excel stored in the cell
+	Filled       bool     `json:"-" parquet:"-"`                             // Added by -dense for a position with no cell in the sheet
+	StyleID      int32    `json:"-" parquet:"-"`                             // Index of the cell's style in Styles.Catalog, set with ReadOptions.Styles
+}
+
+// CellType is the kind of value Excel stored in a cell, from its t attribute and style
+type CellType uint8
+
+const (
+	CellTypeNumber        CellType = iota // t="n" or no t attribute
+	CellTypeSharedString                  // t="s"
+	CellTypeInlineString                  // t="inlineStr"
+	CellTypeFormulaString                 // t="str", the cached string result of a formula
+	CellTypeBool                          // t="b"
+	CellTypeError                         // t="e", e.g. #DIV/0!
+	CellTypeDate                          // t="d", or a number whose style is a date or time format
+)
+
+// cellTypeFromAttr maps a <c> t attribute to a CellType
+func cellTypeFromAttr(t string) CellType {
+	switch t {
+	case "s":
+		return CellTypeSharedString
+	case "inlineStr":
+		return CellTypeInlineString
+	case "str":
+		return CellTypeFormulaString
+	case "b":
+		return CellTypeBool
+	case "e":
+		return CellTypeError
+	case "d":
+		return CellTypeDate
+	}
+	return CellTypeNumber
+}
+
+// String returns the name of the cell type
+func (t CellType) String() string {
+	switch t {
+	case CellTypeNumber:
+		return "number"
+	case CellTypeSharedString:
+		return "shared_string"
+	case CellTypeInlineString:
+		return "inline_string"
+	case CellTypeFormulaString:
+		return "formula_string"
+	case CellTypeBool:
+		return "bool"
+	case CellTypeError:
+		return "error"
+	case CellTypeDate:
+		return "date"
+	}
+	return fmt.Sprintf("CellType(%d)", uint8(t))
+}
+
+// IsString reports whether the cell holds text
+func (t CellType) IsString() bool {
+	return t == CellTypeSharedString || t == CellTypeInlineString || t == CellTypeFormulaString
+}
+
+// AsInt64 returns the value as an integer if the cell is numeric and holds a whole number
+func (d CellData) AsInt64() (int64, bool) {
+	if !d.isNumber() {
+		return 0, false
+	}
+	if n, err := strconv.ParseInt(d.SheetValue, 10, 64); err == nil {
+		return n, true
+	}
+	// Whole numbers may be stored as e.g. "1E+3" or "1.234567890123457E+18". The float
+	// check bounds the value; big.Rat then converts the decimal text exactly, as float64
+	// rounds integers beyond 2^53.
+	f, err := strconv.ParseFloat(d.SheetValue, 64)
+	if err != nil || f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+		return 0, false
+	}
+	r, ok := new(big.Rat).SetString(d.SheetValue)
+	if !ok || !r.IsInt() || !r.Num().IsInt64() {
+		return 0, false
+	}
+	return r.Num().Int64(), true
+}
+
+// AsFloat64 returns the value as a float if the cell is numeric
+func (d CellData) AsFloat64() (float64, bool) {
+	if !d.isNumber() {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(d.SheetValue, 64)
+	return f, err == nil
+}
+
+// AsBool returns the value of a boolean (t="b") cell
+func (d CellData) AsBool() (bool, bool) {
+	if d.Type != CellTypeBool {
+		return false, false
+	}
+	switch d.SheetValue {
+	case "1", "true", "TRUE":
+		return true, true
+	case "0", "false", "FALSE":
+		return false, true
+	}
+	return false, false
+}
+
+// AsTime returns the value of a date cell, either ISO 8601 text (t="d" or converted
+// by -dates) or a serial number, read in the date system of the cell's workbook:
+// date1904 is its Workbook.Date1904. Plain numbers are also accepted as serials.
+func (d CellData) AsTime(date1904 bool) (time.Time, bool) {
+	if d.Type == CellTypeDate {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02", "15:04:05"} {
+			if t, err := time.Parse(layout, d.SheetValue); err == nil {
+				return t, true
+			}
+		}
+	}
+	if serial, ok := d.AsFloat64(); ok && serial >= 0 {
+		return serialToTime(serial, date1904), true
+	}
+	return time.Time{}, false
+}
+
+// isNumber reports whether the cell was stored as a number (date serials included)
+func (d CellData) isNumber() bool {
+	return d.Type == CellTypeNumber || d.Type == CellTypeDate
+}
+
+// ReadOptions controls how sheet data is read
+type ReadOptions struct {
+	ExpandMerged  bool           // Copy each merged region's anchor value to all of its cells
+	FlattenMerged bool           // Copy each merged region's anchor value along its top row only
+	Dense         bool           // Emit every position of the sheet's <dimension>, including empty cells
+	ConvertDates  bool           // Render numbers with a date/time format as ISO dates, times or datetimes
+	CoerceNumbers bool           // Rewrite text and number cells such as "$1,234.50" as plain numbers, see coerceNumber
+	Range         string         // Only keep cells inside this range ("Sheet1!A1:D100", "A1:D100") or defined name
+	Table         string         // Only keep the cells of the Excel table with this name, see ReadTables
+	AutoFilter    bool           // Only keep the cells inside each sheet's <autoFilter> range (ReadSheetData only)
+	Filter        *RowFilter     // Only keep the rows matching this predicate
+	KeepHeaderRow bool           // Keep the first row of each sheet, its header, whatever Filter says
+	Columns       map[int32]bool // Only keep the cells in these columns, applied after Filter
+	ActiveOnly    bool           // Only read the sheet that was active when the workbook was saved
+	MaxColumns    int32          // Drop cells right of this column while reading, 0 for no limit
+	Sample        int            // Only keep every Sample-th <row> of each sheet, starting with the first; 0 or 1 keeps all
+	SheetWorkers  int            // Goroutines decoding one large sheet in ReadSheetData; 0 for GOMAXPROCS, 1 for one
+	Styles        bool           // Set each cell's StyleID; the CLI also adds Styles.Catalog to the metadata sidecar
+	Recover       bool           // Rebuild damaged archives from their local file headers, see OpenRecover
+	Date1904      bool           // The workbook uses the 1904 date system, taken from Workbook.Date1904
+	StaleResults  bool           // The workbook is flagged to recalculate on load, taken from Workbook.FullCalcOnLoad
+
+	// Progress, when set, is called with the sheet name and the number of rows read so far
+	// every ProgressEvery rows (default 100000) and once more when the sheet is done
+	Progress      func(sheetName string, rows int)
+	ProgressEvery int
+
+	// Stats, when set, is called once per sheet when it is decoded, with the bytes, tokens
+	// and cells read and the time taken. Like Warn, it must be safe to call from several
+	// goroutines.
+	Stats func(ParseStats)
+
+	// Warn, when set, receives the problems the reader works around. Sheets are read
+	// concurrently, so it must be safe to call from several goroutines.
+	Warn func(Warning)
+
+	// Transform, when set, is called with each cell as it is decoded and returns the cell
+	// to keep, e.g. with its value normalized or redacted, or false to drop it. It runs
+	// before merged-range flags and dense filling, and is not called for the empty cells
+	// added by Dense. Like Warn, it must be safe to call from several goroutines.
+	Transform func(CellData) (CellData, bool)
+}
+
+// forWorkbook returns the options with the workbook-level settings filled in
+func (o ReadOptions) forWorkbook(workbook *Workbook) ReadOptions {
+	o.Date1904 = workbook.Date1904
+	o.StaleResults = workbook.FullCalcOnLoad
+	return o
+}
+
+// progressEvery returns the progress reporting interval, applying the default
+func (o ReadOptions) progressEvery() int {
+	if o.ProgressEvery <= 0 {
+		return 100_000
+	}
+	return o.ProgressEvery
+}
+
+// Workbook represents the workbook.xml structure, containing sheet names
+type Workbook struct {
+	WorkbookPr struct {
+		Date1904 string `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets struct {
+		Sheet []WorkbookSheet `xml:"sheet"` // In tab order, the canonical order sheets are processed in
+	} `xml:"sheets"`
+	DefinedNames struct {
+		DefinedName []DefinedName `xml:"definedName"`
+	} `xml:"definedNames"`
+	ExternalReferences struct {
+		ExternalReference []ExternalReference `xml:"externalReference"` // In formula index order, see ReadExternalLinks
+	} `xml:"externalReferences"`
+	CalcPr struct {
+		FullCalcOnLoad string `xml:"fullCalcOnLoad,attr"`
+	} `xml:"calcPr"`
+	BookViews struct {
+		WorkbookView []struct {
+			ActiveTab int `xml:"activeTab,attr"`
+		} `xml:"workbookView"`
+	} `xml:"bookViews"`
+
+	Date1904 bool `xml:"-"` // Serial dates count from 1904-01-01 instead of 1900-01-01
+
+	// FullCalcOnLoad is set when <calcPr fullCalcOnLoad="1"> asks Excel to recalculate every
+	// formula on open, a hint that the cached formula results in the file may be outdated
+	FullCalcOnLoad bool `xml:"-"`
+
+	// ActiveTab is the index in the saved sheet list of the sheet that was open when the
+	// workbook was saved, from the first workbookView (0 when absent or out of range).
+	// ActiveSheet is its name.
+	ActiveTab   int    `xml:"-"`
+	ActiveSheet string `xml:"-"`
+}
+
+// WorkbookSheet is a <sheet> entry of workbook.xml
+type WorkbookSheet struct {
+	Name string `xml:"name,attr"`
+	ID   string `xml:"sheetId,attr"`
+	RID  string `xml:"-"` // r:id, bound by namespace URI in UnmarshalXML
+	Path string `xml:"-"` // Worksheet part, resolved through xl/_rels/workbook.xml.rels
+}
+
+// Namespaces of the r:id attribute in Transitional and Strict OOXML workbooks
+const (
+	relationshipsNS       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	strictRelationshipsNS = "http://purl.oclc.org/ooxml/officeDocument/relationships"
+)
+
+// UnmarshalXML reads the sheet attributes. encoding/xml matches attributes by namespace
+// URI, never by prefix, and a struct tag can only name one URI, so r:id is picked out here
+// for both the Transitional and the Strict relationships namespace, whatever its prefix.
+func (s *WorkbookSheet) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch {
+		case attr.Name.Space == "" && attr.Name.Local == "name":
+			s.Name = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == "sheetId":
+			s.ID = attr.Value
+		case attr.Name.Local == "id" && (attr.Name.Space == relationshipsNS || attr.Name.Space == strictRelationshipsNS):
+			s.RID = attr.Value
+		}
+	}
+	return d.Skip()
+}
+
+// DefinedName is a <definedName> entry of workbook.xml, e.g. SalesData -> Sheet1!$A$1:$D$100
+type DefinedName struct {
+	Name         string `xml:"name,attr"`
+	LocalSheetID string `xml:"localSheetId,attr"` // Set when the name is scoped to one sheet
+	Ref          string `xml:",chardata"`
+}
+
+// Relationships represents a .rels part, mapping relationship IDs to target parts
+type Relationships struct {
+	Relationship []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// CellRange is a rectangular block of cells, e.g. "A1:B2"
+type CellRange struct {
+	Range    string
+	StartCol int32
+	StartRow int32
+	EndCol   int32
+	EndRow   int32
+}
+
+// MergedCell describes a merged region of a sheet
+type MergedCell = CellRange
+
+// contains reports whether the given coordinates fall inside the range
+func (m CellRange) contains(col, row int32) bool {
+	return col >= m.StartCol && col <= m.EndCol && row >= m.StartRow && row <= m.EndRow
+}
+
+// size returns the number of cells in the range
+func (m CellRange) size() int64 {
+	return int64(m.EndRow-m.StartRow+1) * int64(m.EndCol-m.StartCol+1)
+}
+
+// parseRangeReference turns a range reference like "A1:B2" (or a single "A1") into a CellRange
+func parseRangeReference(ref string) (CellRange, error) {
+	start, end, found := strings.Cut(ref, ":")
+	if !found {
+		end = start
+	}
+	startCol, startRow, err := ParseRef(start)
+	if err != nil {
+		return CellRange{}, err
+	}
+	endCol, endRow, err := ParseRef(end)
+	if err != nil {
+		return CellRange{}, err
+	}
+	return CellRange{Range: ref, StartCol: startCol, StartRow: startRow, EndCol: endCol, EndRow: endRow}, nil
+}
+
+// sortCells orders cells by row, then column
+func sortCells(cellData []CellData) {
+	sort.SliceStable(cellData, func(i, j int) bool {
+		if cellData[i].RowNumber != cellData[j].RowNumber {
+			return cellData[i].RowNumber < cellData[j].RowNumber
+		}
+		return cellData[i].ColumnNumber < cellData[j].ColumnNumber
+	})
+}
+
+// MaxDenseCells is the largest grid Dense fills, about a gigabyte of cells. A <dimension>
+// may claim the whole sheet, A1:XFD1048576, which no machine could hold as cells.
+const MaxDenseCells = 10_000_000
+
+// fillDense adds an empty cell for every position of bounds that has no cell yet,
+// turning the sparse sheet into a full rectangular grid
+func fillDense(cellData []CellData, bounds CellRange, sheetName string) []CellData {
+	present := make(map[[2]int32]bool, len(cellData))
+	for _, d := range cellData {
+		present[[2]int32{d.RowNumber, d.ColumnNumber}] = true
+	}
+	for row := bounds.StartRow; row <= bounds.EndRow; row++ {
+		for col := bounds.StartCol; col <= bounds.EndCol; col++ {
+			if !present[[2]int32{row, col}] {
+				cellData = append(cellData, CellData{SheetName: sheetName, RowNumber: row, ColumnNumber: col, Filled: true})
+			}
+		}
+	}
+	sortCells(cellData)
+	return cellData
+}
+
+// cellBounds returns the smallest range holding all the cells
+func cellBounds(cellData []CellData) CellRange {
+	var bounds CellRange
+	for i, d := range cellData {
+		if i == 0 || d.RowNumber < bounds.StartRow {
+			bounds.StartRow = d.RowNumber
+		}
+		if i == 0 || d.ColumnNumber < bounds.StartCol {
+			bounds.StartCol = d.ColumnNumber
+		}
+		bounds.EndRow = max(bounds.EndRow, d.RowNumber)
+		bounds.EndCol = max(bounds.EndCol, d.ColumnNumber)
+	}
+	return bounds
+}
+
+// applyMergedCells flags every cell that falls inside one of the merged regions.
+// With expand set, the anchor (top-left) value is copied to every cell of the region,
+// adding the cells Excel left out of the XML. With flatten set, it is only copied along
+// the region's top row, so a header merged across several columns names each of them
+// while the rows below keep their own values.
+func applyMergedCells(cellData []CellData, merges []MergedCell, expand, flatten bool) []CellData {
+	if len(merges) == 0 {
+		return cellData
+	}
+	// Index merged regions by row so each cell only checks the regions on its row
+	mergedMap := make(map[int32][]MergedCell)
+	for _, m := range merges {
+		for row := m.StartRow; row <= m.EndRow; row++ {
+			mergedMap[row] = append(mergedMap[row], m)
+		}
+	}
+	anchors := make(map[string]string) // Merged range -> anchor value
+	present := make(map[[2]int32]bool) // Cells of merged regions found in the XML
+	for i := range cellData {
+		for _, m := range mergedMap[cellData[i].RowNumber] {
+			if m.contains(cellData[i].ColumnNumber, cellData[i].RowNumber) {
+				cellData[i].Merged = true
+				cellData[i].MergedRange = m.Range
+				if cellData[i].ColumnNumber == m.StartCol && cellData[i].RowNumber == m.StartRow {
+					anchors[m.Range] = cellData[i].SheetValue
+				}
+				present[[2]int32{cellData[i].RowNumber, cellData[i].ColumnNumber}] = true
+				break
+			}
+		}
+	}
+	if !expand && !flatten {
+		return cellData
+	}
+	topRows := make(map[string]int32, len(merges))
+	for _, m := range merges {
+		topRows[m.Range] = m.StartRow
+	}
+	fill := func(rangeRef string, row int32) bool {
+		return expand || row == topRows[rangeRef]
+	}
+
+	for i := range cellData {
+		if cellData[i].Merged && fill(cellData[i].MergedRange, cellData[i].RowNumber) {
+			cellData[i].SheetValue = anchors[cellData[i].MergedRange]
+			cellData[i].Filled = false // A -dense position now holding the anchor's value is no longer a gap
+		}
+	}
+	// Fill in the merged positions that had no <c> element at all
+	sheetName := ""
+	if len(cellData) > 0 {
+		sheetName = cellData[0].SheetName
+	}
+	added := false
+	for _, m := range merges {
+		for row := m.StartRow; row <= m.EndRow; row++ {
+			if !fill(m.Range, row) {
+				break
+			}
+			for col := m.StartCol; col <= m.EndCol; col++ {
+				if present[[2]int32{row, col}] {
+					continue
+				}
+				cellData = append(cellData, CellData{
+					SheetName:    sheetName,
+					RowNumber:    row,
+					ColumnNumber: col,
+					SheetValue:   anchors[m.Range],
+					Merged:       true,
+					MergedRange:  m.Range,
+				})
+				added = true
+			}
+		}
+	}
+	if added {
+		sortCells(cellData)
+	}
+	return cellData
+}
+
+// Excel's sheet limits: column XFD and row 1,048,576
+const (
+	MaxColumns = 16384
+	MaxRows    = 1048576
+)
+
+// ParseRef takes a cell reference like "A1" and returns the column and row numbers.
+// References beyond XFD1048576 are rejected rather than wrapped around.
+func ParseRef(ref string) (int32, int32, error) {
+	var col int32 = 0
+	var row int32 = 0
+	for i := 0; i < len(ref); i++ {
+		if ref[i] >= 'A' && ref[i] <= 'Z' { // Process the column letters
+			// Convert letter to a column number (A = 1, B = 2, ..., Z = 26, AA = 27, etc.)
+			col = col*26 + int32(ref[i]-'A'+1)
+			if col > MaxColumns {
+				return 0, 0, fmt.Errorf("cell reference %q: column is beyond XFD", ref)
+			}
+		} else {
+			// Process the row part by slicing the remaining string and converting it to an integer
+			rowPart, err := strconv.Atoi(ref[i:])
+			if err != nil {
+				return 0, 0, fmt.Errorf("cell reference %q: invalid row", ref)
+			}
+			if rowPart < 1 || rowPart > MaxRows {
+				return 0, 0, fmt.Errorf("cell reference %q: row is outside 1-%d", ref, MaxRows)
+			}
+			row = int32(rowPart)
+			break
+		}
+	}
+	return col, row, nil
+}
+
+// FormatRef is the inverse of ParseRef, e.g. (28, 3) -> "AB3".
+// It returns "" when the column or row is not positive.
+func FormatRef(col, row int32) string {
+	if col < 1 || row < 1 {
+		return ""
+	}
+	return IndexToColumn(col) + strconv.Itoa(int(row))
+}
+
+// IndexToColumn converts a column number to its letters (1 = A, 27 = AA).
+// It returns "" for columns below 1.
+func IndexToColumn(col int32) string {
+	var letters [8]byte // Enough for any int32
+	i := len(letters)
+	for col > 0 {
+		col-- // Bijective base 26: there is no zero digit
+		i--
+		letters[i] = byte('A' + col%26)
+		col /= 26
+	}
+	return string(letters[i:])
+}
+
+// ColumnToIndex converts column letters to a column number (A = 1, AA = 27)
+func ColumnToIndex(letters string) (int32, error) {
+	if letters == "" || strings.Trim(letters, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return 0, fmt.Errorf("invalid column %q", letters)
+	}
+	col, _, err := ParseRef(letters)
+	return col, err
+}
+
+// Utility: Get cell value, handles shared strings. A shared string index that is not in
+// the table gives an empty value rather than the index itself; streamSheet warns about it.
+func getCellValue(cell Cell, sharedStrings *SharedStrings) string {
+	if cell.T == "s" {
+		idx, err := strconv.Atoi(cell.V)
+		if err != nil || idx < 0 || idx >= len(sharedStrings.Items) {
+			return ""
+		}
+		return sharedStrings.Items[idx]
+	}
+	return cell.V
+}
+
+// MissingSheetError is returned for a sheet that workbook.xml lists but whose worksheet
+// part is not in the archive, e.g. in a file written by a broken tool or recovered with
+// parts lost. Use errors.As to tell it apart from sheets that fail to decode.
+type MissingSheetError struct {
+	Sheet string // Sheet name, as listed in workbook.xml
+	Path  string // The worksheet part the workbook relationships point to
+}
+
+func (e *MissingSheetError) Error() string {
+	return fmt.Sprintf("worksheet part %s is missing from the archive", e.Path)
+}
+
+// sheetExtras holds the sheet-level elements collected while streaming a sheet
+type sheetExtras struct {
+	dimension  *CellRange   // The sheet's <dimension>, only kept when opts.Dense is set
+	autoFilter *CellRange   // The sheet's <autoFilter> range, only kept when opts.AutoFilter is set
+	merges     []MergedCell // The <mergeCells> list, which follows <sheetData>
+
+	// Columns covered by the rows' spans attributes, 0 when no row has one
+	spanStart, spanEnd int32
+
+	rows int // <row> elements read, sampled or not
+	// The numbers of the first and last rows, for checking the row order across the
+	// chunks of a split sheet
+	firstRow, lastRow int32
+	formulas          int // Cells with a <f> formula
+
+	// Counters for SheetStats
+	bytes  int64 // Uncompressed bytes of the part, set by the caller of decodeSheet
+	tokens int64 // XML tokens decoded
+	cells  int   // Cells emitted
+}
+
+// parseSpans reads a <row> spans attribute, a list of column ranges like "1:8" or
+// "1:3 5:8", and returns the first and last column it covers. ok is false when the
+// attribute is malformed, as it is only an optimization hint.
+func parseSpans(value string) (first, last int32, ok bool) {
+	for _, span := range strings.Fields(value) {
+		lo, hi, found := strings.Cut(span, ":")
+		if !found {
+			hi = lo
+		}
+		start, startErr := strconv.ParseInt(lo, 10, 32)
+		end, endErr := strconv.ParseInt(hi, 10, 32)
+		if startErr != nil || endErr != nil || start < 1 || end < start || end > MaxColumns {
+			return 0, 0, false
+		}
+		if !ok || int32(start) < first {
+			first = int32(start)
+		}
+		last = max(last, int32(end))
+		ok = true
+	}
+	return first, last, ok
+}
+
+// streamSheet decodes a worksheet part using xml.RawToken for performance and calls emit
+// for the cells of each row once its </row> is read. An error from emit stops the decode and is returned.
+func streamSheet(zipReader archive, sheetName, fileName string, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions, emit func(CellData) error) (*sheetExtras, error) {
+	file := zipReader.find(fileName)
+	if file == nil {
+		return nil, &MissingSheetError{Sheet: sheetName, Path: fileName}
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	counter := &countingReader{r: f}
+	extras, err := decodeSheet(partReader(counter, readBufferSize(file.UncompressedSize64)), sheetName, sharedStrings, styles, opts, emit)
+	if err != nil {
+		return nil, err
+	}
+	extras.bytes = counter.n
+	finishSheet(sheetName, extras, opts, start)
+	return extras, nil
+}
+
+// finishSheet reports the final row count, the statistics and the sheet-level warnings
+// once a sheet is decoded
+func finishSheet(sheetName string, extras *sheetExtras, opts ReadOptions, start time.Time) {
+	if opts.Progress != nil && extras.rows%opts.progressEvery() != 0 {
+		opts.Progress(sheetName, extras.rows) // Final count for the sheet
+	}
+	if opts.Stats != nil {
+		opts.Stats(ParseStats{Sheet: sheetName, Bytes: extras.bytes, Tokens: extras.tokens, Rows: extras.rows, Cells: extras.cells, Duration: time.Since(start)})
+	}
+	if opts.StaleResults && extras.formulas > 0 {
+		opts.warn(sheetName, "", "%d formula values are cached results and may be outdated: the workbook is flagged to recalculate on load", extras.formulas)
+	}
+}
+
+// decodeSheet is the decode loop of streamSheet, reading worksheet XML from r. The rows
+// may also be a chunk of the part cut at a <row> boundary, see readSheetSplit.
+func decodeSheet(r io.Reader, sheetName string, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions, emit func(CellData) error) (*sheetExtras, error) {
+	decoder := newXMLDecoder(r)
+	var currentRow int32
+	var currentCol int32
+	var currentValue string
+	var cell Cell // Define cell variable here
+	var extras sheetExtras
+	var rowsRead, formulas, cells int
+	var tokens int64
+	var inlineText strings.Builder
+	var inInlineString, inPhonetic bool
+	var inCustomView bool // Inside a <customSheetView>, whose <autoFilter> is not the sheet's
+	var implicitRow bool  // The current row has no valid r attribute
+
+	// Cells are held until their row ends, so a repeated reference can replace the earlier cell
+	var rowCells []CellData
+	rowColumns := make(map[int32]int) // Column -> index in rowCells
+	flushRow := func() error {
+		for _, d := range rowCells {
+			if opts.CoerceNumbers && (d.Type == CellTypeNumber || d.Type.IsString()) {
+				if value, ok := coerceNumber(d.SheetValue); ok {
+					d.SheetValue, d.Type = value, CellTypeNumber
+				}
+			}
+			if opts.Transform != nil {
+				var keep bool
+				if d, keep = opts.Transform(d); !keep {
+					continue
+				}
+			}
+			if err := emit(d); err != nil {
+				return err
+			}
+			cells++
+		}
+		rowCells = rowCells[:0]
+		clear(rowColumns)
+		return nil
+	}
+	endRow := func() {
+		rowsRead++
+		if opts.Progress != nil && rowsRead%opts.progressEvery() == 0 {
+			opts.Progress(sheetName, rowsRead)
+		}
+	}
+
+	// RawToken will return tokens without unnecessary overhead
+	for {
+		t, err := decoder.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		tokens++
+
+		switch token := t.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "row":
+				// Capture row number from the attributes. The r attribute is optional: a row
+				// without it, or with an invalid one, follows the previous row unless its
+				// first cell's reference says otherwise.
+				previousRow := currentRow
+				currentRow++
+				implicitRow = true
+				currentCol = 0
+				for _, attr := range token.Attr {
+					switch attr.Name.Local {
+					case "r":
+						if attr.Value == "" {
+							continue // Written as r="" by some tools, same as no r
+						}
+						rowInt, err := strconv.ParseInt(attr.Value, 10, 32)
+						if err != nil || rowInt < 1 || rowInt > MaxRows {
+							opts.warn(sheetName, "", "row number %q is not between 1 and %d, numbering it %d", attr.Value, MaxRows, currentRow)
+							continue
+						}
+						currentRow, implicitRow = int32(rowInt), false
+						if extras.firstRow == 0 {
+							extras.firstRow = currentRow
+						}
+						if currentRow <= previousRow {
+							opts.warn(sheetName, "", "row %d is out of order, it comes after row %d", currentRow, previousRow)
+						}
+					case "spans":
+						// The row's used columns: size the row buffer up front and
+						// remember the width for -dense when there is no <dimension>
+						first, last, ok := parseSpans(attr.Value)
+						if !ok {
+							continue
+						}
+						rowCells = slices.Grow(rowCells, int(last-first+1))
+						if extras.spanEnd == 0 || first < extras.spanStart {
+							extras.spanStart = first
+						}
+						extras.spanEnd = max(extras.spanEnd, last)
+					}
+				}
+				if opts.Sample > 1 && rowsRead%opts.Sample != 0 {
+					// Not sampled: skip to </row> without decoding the cells
+					skipped, err := skipRawElement(decoder)
+					tokens += skipped
+					if err != nil {
+						return nil, err
+					}
+					endRow()
+				}
+			case "c":
+				// Capture cell reference (e.g., A1) and type (e.g., "s" for shared string)
+				cell = Cell{}     // Reinitialize cell variable for each <c> element
+				currentValue = "" // A cell without <v> or <is> is empty, not the previous cell's value
+				for _, attr := range token.Attr {
+					switch attr.Name.Local {
+					case "r":
+						if attr.Value == "" {
+							continue // Same as no r: the cell follows the previous one
+						}
+						cell.R = attr.Value
+						var refRow int32
+						if currentCol, refRow, err = ParseRef(attr.Value); err != nil {
+							return nil, err
+						}
+						if implicitRow && refRow >= 1 {
+							currentRow = refRow // The row has no number of its own, take the cell's
+						} else if refRow != currentRow {
+							opts.warn(sheetName, attr.Value, "cell is inside row %d", currentRow)
+						}
+					case "t":
+						cell.T = attr.Value
+					case "s":
+						cell.S = attr.Value
+					}
+				}
+				if cell.R == "" {
+					currentCol++ // The reference is optional; the cell then follows the previous one in the row
+				}
+				implicitRow = false
+			case "v":
+				// Capture the text between <v>...</v>, whether it comes before or after <f>.
+				// <v xsi:nil="true"/> marks a missing value and leaves the cell empty.
+				var value strings.Builder
+				for {
+					t, err := decoder.RawToken()
+					if err != nil {
+						return nil, err
+					}
+					tokens++
+					if charData, ok := t.(xml.CharData); ok {
+						value.Write(charData)
+					} else if _, ok := t.(xml.EndElement); ok {
+						break
+					}
+				}
+				currentValue = value.String()
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "nil" && parseXMLBool(attr.Value) {
+						currentValue = ""
+					}
+				}
+			case "f":
+				formulas++ // The cell's value is the cached result of this formula
+			case "is":
+				// Inline string (t="inlineStr"): plain <t>, or rich text runs joined in order
+				inInlineString = true
+				inlineText.Reset()
+			case "rPh":
+				inPhonetic = true // Phonetic guide text is not part of the displayed value
+			case "t":
+				if !inInlineString || inPhonetic {
+					continue
+				}
+				for {
+					t, err := decoder.RawToken()
+					if err != nil {
+						return nil, err
+					}
+					tokens++
+					if charData, ok := t.(xml.CharData); ok {
+						inlineText.Write(charData)
+					} else if _, ok := t.(xml.EndElement); ok {
+						break
+					}
+				}
+			case "dimension":
+				// The used range of the sheet, only needed to fill a dense grid
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "ref" && opts.Dense {
+						bounds, err := parseRangeReference(attr.Value)
+						if err != nil {
+							return nil, err
+						}
+						extras.dimension = &bounds
+					}
+				}
+			case "customSheetView":
+				inCustomView = true
+			case "autoFilter":
+				// Also after <sheetData>, so cells can only be limited to it by ReadSheetData
+				if inCustomView {
+					continue
+				}
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "ref" && opts.AutoFilter {
+						bounds, err := parseRangeReference(strings.ReplaceAll(attr.Value, "$", ""))
+						if err != nil {
+							return nil, err
+						}
+						extras.autoFilter = &bounds
+					}
+				}
+			case "mergeCell":
+				// Merged regions are listed after <sheetData>, so they are applied once the sheet is read
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "ref" {
+						merge, err := parseRangeReference(attr.Value)
+						if err != nil {
+							return nil, err
+						}
+						extras.merges = append(extras.merges, merge)
+					}
+				}
+			}
+
+		case xml.EndElement:
+			switch token.Name.Local {
+			case "is":
+				inInlineString = false
+				currentValue = inlineText.String()
+			case "rPh":
+				inPhonetic = false
+			case "customSheetView":
+				inCustomView = false
+			case "c":
+				// Finished processing a cell, get the value. Pretty-printed files may pad
+				// <v> with whitespace, which is only part of the value for formula strings
+				// (and inline strings, read from <is>).
+				if cell.T != "str" && cell.T != "inlineStr" {
+					currentValue = strings.TrimSpace(currentValue)
+				}
+				if cell.T == "s" {
+					if idx, err := strconv.Atoi(currentValue); err != nil || idx < 0 || idx >= len(sharedStrings.Items) {
+						opts.warn(sheetName, FormatRef(currentCol, currentRow), "shared string index %q is not in the table of %d strings, the cell is left empty", currentValue, len(sharedStrings.Items))
+					}
+				}
+				val := getCellValue(Cell{T: cell.T, V: currentValue}, sharedStrings)
+				cellType := cellTypeFromAttr(cell.T)
+				if cellType == CellTypeNumber {
+					if kind := styles.dateKindOf(cell.S); kind != notDate {
+						cellType = CellTypeDate
+						if opts.ConvertDates {
+							val = formatSerialDate(val, kind, opts.Date1904)
+						}
+					}
+				}
+				d := CellData{
+					SheetName:    sheetName,
+					RowNumber:    currentRow,
+					ColumnNumber: currentCol,
+					SheetValue:   val,
+					Type:         cellType,
+				}
+				if opts.Styles && cell.S != "" {
+					d.StyleID = parseInt32(cell.S)
+				}
+				if opts.MaxColumns > 0 && currentCol > opts.MaxColumns {
+					continue // Beyond -max-cols
+				}
+				// Malformed files may repeat a reference within a row; like Excel, keep the last
+				if i, dup := rowColumns[currentCol]; dup {
+					opts.warn(sheetName, FormatRef(currentCol, currentRow), "duplicate cell, keeping the last one")
+					rowCells[i] = d
+				} else {
+					rowColumns[currentCol] = len(rowCells)
+					rowCells = append(rowCells, d)
+				}
+			case "row":
+				if err := flushRow(); err != nil {
+					return nil, err
+				}
+				endRow()
+			}
+		}
+	}
+	if err := flushRow(); err != nil { // Cells outside of any <row>
+		return nil, err
+	}
+	extras.rows, extras.formulas = rowsRead, formulas
+	extras.lastRow = currentRow
+	extras.tokens, extras.cells = tokens, cells
+	return &extras, nil
+}
+
+// skipRawElement reads the tokens up to the end of the element whose start element was
+// just returned by RawToken and returns how many it read. Decoder.Skip cannot be used, as
+// it expects Token.
+func skipRawElement(decoder *xml.Decoder) (int64, error) {
+	var tokens int64
+	for depth := 1; depth > 0; {
+		t, err := decoder.RawToken()
+		if err != nil {
+			return tokens, err
+		}
+		tokens++
+		switch t.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return tokens, nil
+}
+
+// StreamSheet decodes a worksheet part and calls emit for each cell as it is read, so
+// memory stays constant regardless of sheet size. Merged-range flags, -dense filling and
+// the AutoFilter option need the whole sheet and are only applied by ReadSheetData.
+func StreamSheet(zipReader *zip.Reader, sheetName, fileName string, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions, emit func(CellData) error) error {
+	_, err := streamSheet(archive{Reader: zipReader}, sheetName, fileName, sharedStrings, styles, opts, emit)
+	return err
+}
+
+// Read sheet data and return parsed cell data using xml.RawToken for performance
+func ReadSheetData(zipReader *zip.Reader, sheetName, fileName string, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions) ([]CellData, error) {
+	return readSheetData(archive{Reader: zipReader}, sheetName, fileName, sharedStrings, styles, opts)
+}
+
+func readSheetData(zipReader archive, sheetName, fileName string, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions) ([]CellData, error) {
+	cellData, extras, err := readSheetCells(zipReader, sheetName, fileName, sharedStrings, styles, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Rows stored out of order, which Excel never writes, are put back in row order
+	if !slices.IsSortedFunc(cellData, func(a, b CellData) int { return cmp.Compare(a.RowNumber, b.RowNumber) }) {
+		sortCells(cellData)
+	}
+
+	if opts.MaxColumns > 0 {
+		// Neither the dense grid nor expanded merged ranges may reach past the limit
+		if extras.dimension != nil {
+			extras.dimension.EndCol = min(extras.dimension.EndCol, opts.MaxColumns)
+		}
+		extras.spanEnd = min(extras.spanEnd, opts.MaxColumns)
+		for i := range extras.merges {
+			extras.merges[i].EndCol = min(extras.merges[i].EndCol, opts.MaxColumns)
+		}
+	}
+	if opts.Dense && len(cellData) > 0 {
+		dimension := extras.dimension
+		if dimension == nil {
+			bounds := cellBounds(cellData) // No <dimension>, use the cells that are there
+			if extras.spanEnd >= extras.spanStart && extras.spanEnd > 0 {
+				// The rows' spans give the sheet's width, which may exceed the cells read
+				bounds.StartCol = min(bounds.StartCol, extras.spanStart)
+				bounds.EndCol = max(bounds.EndCol, extras.spanEnd)
+			}
+			dimension = &bounds
+		}
+		if dimension.size() > MaxDenseCells {
+			// Writers may store a <dimension> far larger than the cells, so fall back to those
+			bounds := cellBounds(cellData)
+			if bounds.size() > MaxDenseCells {
+				return nil, fmt.Errorf("the cells span %d positions, more than the %d -dense fills", bounds.size(), MaxDenseCells)
+			}
+			opts.warn(sheetName, dimension.Range, "dimension has %d cells, more than the %d -dense fills, filling the cells' range %s instead", dimension.size(), MaxDenseCells, FormatRef(bounds.StartCol, bounds.StartRow)+":"+FormatRef(bounds.EndCol, bounds.EndRow))
+			dimension = &bounds
+		}
+		cellData = fillDense(cellData, *dimension, sheetName)
+	}
+	cellData = applyMergedCells(cellData, extras.merges, opts.ExpandMerged, opts.FlattenMerged)
+	if opts.AutoFilter {
+		if extras.autoFilter == nil {
+			opts.warn(sheetName, "", "no autoFilter range, sheet skipped")
+			return nil, nil
+		}
+		cellData = slices.DeleteFunc(cellData, func(d CellData) bool {
+			return !extras.autoFilter.contains(d.ColumnNumber, d.RowNumber)
+		})
+	}
+	return cellData, nil
+}
+
+// stringItem is a <si> shared string: plain text in <t>, or rich text split into <r> runs.
+// Phonetic guides (<rPh>, furigana in East-Asian workbooks) also hold a <t> but are not
+// part of the displayed value, so they are not mapped and get skipped by the decoder.
+type stringItem struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+// text returns the displayed value of the item
+func (si stringItem) text() string {
+	if len(si.Runs) == 0 {
+		return si.T
+	}
+	var b strings.Builder
+	b.WriteString(si.T)
+	for _, run := range si.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+// ReadSharedStrings extracts shared strings from an XLSX file.
+// A missing xl/sharedStrings.xml is not an error and yields an empty table.
+func ReadSharedStrings(zipReader *zip.Reader) (*SharedStrings, error) {
+	return readSharedStrings(archive{Reader: zipReader})
+}
+
+func readSharedStrings(zipReader archive) (*SharedStrings, error) {
+	file := zipReader.find("xl/sharedStrings.xml")
+	if file == nil {
+		// Workbooks with only numbers or inline strings have no shared strings part
+		return &SharedStrings{}, nil
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	decoder := newXMLDecoder(partReader(f, readBufferSize(file.UncompressedSize64)))
+
+	var sharedStrings SharedStrings
+	for {
+		t, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		switch se := t.(type) {
+		case xml.StartElement:
+			if se.Name.Local == "si" {
+				// Every <si> takes a slot, including empty <si/> and <si><t/></si>,
+				// so that the indices used by t="s" cells stay aligned
+				var item stringItem
+				if err := decoder.DecodeElement(&item, &se); err != nil {
+					return nil, fmt.Errorf("shared string %d: %w", len(sharedStrings.Items), err)
+				}
+				sharedStrings.Items = append(sharedStrings.Items, item.text())
+			}
+		}
+	}
+
+	// Debugging statement to print shared string size
+	sharedStringCount := len(sharedStrings.Items)
+
+	// Optional: warn if shared string count exceeds a threshold
+	if sharedStringCount > 1000_000 {
+		slog.Warn("large shared strings table, consider optimizing lookup", "count", sharedStringCount)
+	}
+
+	return &sharedStrings, nil
+}
+
+// Read the workbook structure and resolve each sheet's worksheet part
+func ReadWorkbook(zipReader *zip.Reader) (*Workbook, error) {
+	return readWorkbook(archive{Reader: zipReader})
+}
+
+func readWorkbook(zipReader archive) (*Workbook, error) {
+	var workbook Workbook
+	if zipReader.find("xl/workbook.xml") == nil {
+		// An .xlsb has xl/workbook.bin instead, say so instead of "not found"
+		if err := detectBinaryWorkbook(zipReader); err != nil {
+			return &workbook, err
+		}
+	}
+	if err := readXMLFromZip(zipReader, "xl/workbook.xml", &workbook); err != nil {
+		return &workbook, err
+	}
+	if len(workbook.Sheets.Sheet) == 0 {
+		return &workbook, ErrNoSheets
+	}
+	workbook.Date1904 = parseXMLBool(workbook.WorkbookPr.Date1904)
+	workbook.FullCalcOnLoad = parseXMLBool(workbook.CalcPr.FullCalcOnLoad)
+	if views := workbook.BookViews.WorkbookView; len(views) > 0 && views[0].ActiveTab < len(workbook.Sheets.Sheet) {
+		workbook.ActiveTab = max(views[0].ActiveTab, 0)
+	}
+	workbook.ActiveSheet = workbook.Sheets.Sheet[workbook.ActiveTab].Name
+
+	rels, err := readWorkbookRels(zipReader)
+	if err != nil {
+		return &workbook, err
+	}
+	for i := range workbook.Sheets.Sheet {
+		sheet := &workbook.Sheets.Sheet[i]
+		if target, ok := rels[sheet.RID]; ok {
+			sheet.Path = target
+		} else {
+			// No usable relationship, fall back to the conventional part name
+			sheet.Path = fmt.Sprintf("xl/worksheets/sheet%s.xml", sheet.ID)
+		}
+	}
+	return &workbook, nil
+}
+
+// ReadWorkbookRels reads xl/_rels/workbook.xml.rels and returns relationship ID -> part path.
+// A workbook without the part yields an empty map.
+func ReadWorkbookRels(zipReader *zip.Reader) (map[string]string, error) {
+	return readWorkbookRels(archive{Reader: zipReader})
+}
+
+func readWorkbookRels(zipReader archive) (map[string]string, error) {
+	var rels Relationships
+	if zipReader.find("xl/_rels/workbook.xml.rels") == nil {
+		return map[string]string{}, nil
+	}
+	if err := readXMLFromZip(zipReader, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationship))
+	for _, rel := range rels.Relationship {
+		targets[rel.ID] = resolvePartPath("xl", rel.Target)
+	}
+	return targets, nil
+}
+
+// resolvePartPath turns a relationship target into a zip entry name. Targets are
+// relative to the directory of the source part unless they start with "/".
+func resolvePartPath(baseDir, target string) string {
+	target = strings.ReplaceAll(target, "\\", "/")
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(path.Clean(target), "/")
+	}
+	return path.Join(baseDir, target)
+}
+
+// archive is a workbook's zip archive, with the part name index of an open Document.
+// Each exported function reading parts takes a *zip.Reader, which it scans for the parts
+// it needs, and hands it to an unexported counterpart taking an archive; a Document
+// calls the counterparts directly with its index.
+type archive struct {
+	*zip.Reader
+	index map[string]*zip.File // Lower-cased part name -> file, nil to scan the archive
+}
+
+// indexArchive builds the lower-cased part name -> file index of an archive. The first
+// of several parts differing only in case wins, as with a scan.
+func indexArchive(zipReader *zip.Reader) archive {
+	index := make(map[string]*zip.File, len(zipReader.File))
+	for _, file := range zipReader.File {
+		key := strings.ToLower(file.Name)
+		if _, exists := index[key]; !exists {
+			index[key] = file
+		}
+	}
+	return archive{Reader: zipReader, index: index}
+}
+
+// find returns the named part of the archive, or nil if it is absent.
+// OPC part names are case-insensitive, so e.g. xl/SharedStrings.xml matches too.
+func (a archive) find(name string) *zip.File {
+	if a.index != nil {
+		return a.index[strings.ToLower(name)]
+	}
+	for _, file := range a.File {
+		if strings.EqualFold(file.Name, name) {
+			return file
+		}
+	}
+	return nil
+}
+
+// Generalized XML reading helper
+func readXMLFromZip(zipReader archive, filePath string, data interface{}) error {
+	file := zipReader.find(filePath)
+	if file == nil {
+		return fmt.Errorf("%s not found", filePath)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	decoder := newXMLDecoder(partReader(f, readBufferSize(file.UncompressedSize64)))
+	return decoder.Decode(data)
+}
+
+// processSheetsConcurrently reads every sheet of the workbook on its own goroutine and
+// appends the cells to data in tab order, the order of the <sheet> elements in workbook.xml,
+// whichever sheet finishes first. Neither sheetId nor the worksheet part names are used for
+// ordering, as they need not match the tabs. A sheet that fails does not stop the others;
+// its error is returned.
+func processSheetsConcurrently(zipReader archive, workbook *Workbook, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions, data *[]CellData, wg *sync.WaitGroup) []error {
+	// Each worker fills its own slot, so no locking is needed and the order stays stable
+	sheetData := make([][]CellData, len(workbook.Sheets.Sheet))
+	errs := make([]error, len(workbook.Sheets.Sheet))
+	opts = opts.forWorkbook(workbook)
+	for i, sheet := range workbook.Sheets.Sheet {
+		wg.Add(1)
+		go func(i int, sheetName, sheetFile string) {
+			defer wg.Done()
+			// The cells already carry Merged/MergedRange, applied per sheet inside ReadSheetData
+			sheetData[i], errs[i] = readSheetData(zipReader, sheetName, sheetFile, sharedStrings, styles, opts)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("sheet %s: %w", sheetName, errs[i])
+			}
+		}(i, sheet.Name, sheet.Path)
+	}
+	wg.Wait()
+
+	var sheetErrors []error
+	for i := range sheetData {
+		*data = append(*data, sheetData[i]...)
+		if errs[i] != nil {
+			sheetErrors = append(sheetErrors, errs[i])
+		}
+	}
+	return sheetErrors
+}
@@ -0,0 +1,300 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestRefRoundTrip(t *testing.T) {
+	rows := []int32{1, 2, 9, 10, 99, 1000, MaxRows}
+	for col := int32(1); col <= MaxColumns; col++ {
+		letters := IndexToColumn(col)
+		if got, err := ColumnToIndex(letters); err != nil || got != col {
+			t.Fatalf("ColumnToIndex(%q) = %d, %v, want %d", letters, got, err, col)
+		}
+		for _, row := range rows {
+			ref := FormatRef(col, row)
+			gotCol, gotRow, err := ParseRef(ref)
+			if err != nil || gotCol != col || gotRow != row {
+				t.Fatalf("ParseRef(%q) = %d, %d, %v, want %d, %d", ref, gotCol, gotRow, err, col, row)
+			}
+		}
+	}
+}
+
+func TestParseRefLimits(t *testing.T) {
+	tests := []struct {
+		ref     string
+		col     int32
+		row     int32
+		wantErr bool
+	}{
+		{ref: "A1", col: 1, row: 1},
+		{ref: "XFD1048576", col: MaxColumns, row: MaxRows},
+		{ref: "XFE1", wantErr: true},
+		{ref: "A1048577", wantErr: true},
+		{ref: "A0", wantErr: true},
+		{ref: "A1x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			col, row, err := ParseRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRef(%q) error = %v, want error %v", tt.ref, err, tt.wantErr)
+			}
+			if !tt.wantErr && (col != tt.col || row != tt.row) {
+				t.Errorf("ParseRef(%q) = %d, %d, want %d, %d", tt.ref, col, row, tt.col, tt.row)
+			}
+		})
+	}
+}
+
+func TestIndexToColumn(t *testing.T) {
+	tests := []struct {
+		col  int32
+		want string
+	}{
+		{1, "A"},
+		{26, "Z"},
+		{27, "AA"},
+		{52, "AZ"},
+		{53, "BA"},
+		{702, "ZZ"},
+		{703, "AAA"},
+		{MaxColumns, "XFD"},
+		{0, ""},
+		{-1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := IndexToColumn(tt.col); got != tt.want {
+				t.Errorf("IndexToColumn(%d) = %q, want %q", tt.col, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatRefInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		col, row int32
+	}{
+		{"zero column", 0, 1},
+		{"negative column", -3, 1},
+		{"zero row", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatRef(tt.col, tt.row); got != "" {
+				t.Errorf("FormatRef(%d, %d) = %q, want \"\"", tt.col, tt.row, got)
+			}
+		})
+	}
+}
+
+func TestEmptySharedStrings(t *testing.T) {
+	file := readTestFile(t, "emptysi.xlsx", ReadOptions{})
+	cells := cellsByRef(file.Data)
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"A1", "a"},
+		{"B1", ""}, // <si><t/></si>
+		{"C1", ""}, // <si/>
+		{"D1", "d"},
+		{"E1", "ef"},
+		{"F1", "g"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := cells[tt.ref].SheetValue; got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+	if len(file.Warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", file.Warnings)
+	}
+}
+
+func TestDuplicateCells(t *testing.T) {
+	file := readTestFile(t, "duplicate.xlsx", ReadOptions{})
+	want := []struct {
+		ref   string
+		value string
+	}{
+		{"A1", "1"},
+		{"B1", "3"}, // The last of the two B1 cells, as Excel keeps
+		{"A2", "4"},
+	}
+	if len(file.Data) != len(want) {
+		t.Fatalf("got %d cells, want %d", len(file.Data), len(want))
+	}
+	for i, w := range want {
+		if d := file.Data[i]; FormatRef(d.ColumnNumber, d.RowNumber) != w.ref || d.SheetValue != w.value {
+			t.Errorf("cell %d = %s %q, want %s %q", i, FormatRef(d.ColumnNumber, d.RowNumber), d.SheetValue, w.ref, w.value)
+		}
+	}
+	if len(file.Warnings) != 1 || file.Warnings[0].Ref != "B1" {
+		t.Errorf("warnings = %v, want one about B1", file.Warnings)
+	}
+}
+
+func TestInlineStrings(t *testing.T) {
+	cells := cellsByRef(readTestFile(t, "inline.xlsx", ReadOptions{}).Data)
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{"two runs", "A1", "ab"},
+		{"plain text", "B1", "x"},
+		{"preserved spaces", "C1", " y "},
+		{"phonetic guide left out", "D1", "漢"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := cells[tt.ref]; d.SheetValue != tt.want || d.Type != CellTypeInlineString {
+				t.Errorf("%s = %q (type %d), want inline string %q", tt.ref, d.SheetValue, d.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestSharedStringOutOfRange(t *testing.T) {
+	file := readTestFile(t, "badsst.xlsx", ReadOptions{})
+	cells := cellsByRef(file.Data)
+	warned := make(map[string]bool)
+	for _, w := range file.Warnings {
+		warned[w.Ref] = true
+	}
+	tests := []struct {
+		ref  string
+		want string
+		warn bool
+	}{
+		{"A1", "ok", false},
+		{"B1", "", true}, // Past the end of the table
+		{"C1", "", true}, // Negative
+		{"D1", "", true}, // Not a number
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := cells[tt.ref].SheetValue; got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.ref, got, tt.want)
+			}
+			if warned[tt.ref] != tt.warn {
+				t.Errorf("%s warned %v, want %v", tt.ref, warned[tt.ref], tt.warn)
+			}
+		})
+	}
+}
+
+func TestRowNumbering(t *testing.T) {
+	file := readTestFile(t, "rows.xlsx", ReadOptions{})
+	want := []struct {
+		ref   string
+		value string
+	}{
+		{"A1", "1"}, // No r: the first row
+		{"A3", "3"}, // Stored after row 5, read in row order
+		{"A5", "5"},
+		{"A6", "6"}, // No r: the row after 5
+		{"B9", "9"}, // No r on the row, taken from its cell
+	}
+	if len(file.Data) != len(want) {
+		t.Fatalf("got %d cells, want %d", len(file.Data), len(want))
+	}
+	for i, w := range want {
+		if d := file.Data[i]; FormatRef(d.ColumnNumber, d.RowNumber) != w.ref || d.SheetValue != w.value {
+			t.Errorf("cell %d = %s %q, want %s %q", i, FormatRef(d.ColumnNumber, d.RowNumber), d.SheetValue, w.ref, w.value)
+		}
+	}
+}
+
+func TestPositionalColumns(t *testing.T) {
+	file := readTestFile(t, "cols.xlsx", ReadOptions{})
+	want := []struct {
+		ref   string
+		value string
+	}{
+		{"A1", "a"}, // No r: the first column
+		{"B1", "b"}, // The column after the previous cell
+		{"E1", "e"},
+		{"F1", "f"}, // Counting on from an explicit reference
+		{"C2", "c"},
+		{"D2", ""}, // An empty <c/> still takes its position
+		{"E2", "e"},
+	}
+	if len(file.Data) != len(want) {
+		t.Fatalf("got %d cells, want %d", len(file.Data), len(want))
+	}
+	for i, w := range want {
+		if d := file.Data[i]; FormatRef(d.ColumnNumber, d.RowNumber) != w.ref || d.SheetValue != w.value {
+			t.Errorf("cell %d = %s %q, want %s %q", i, FormatRef(d.ColumnNumber, d.RowNumber), d.SheetValue, w.ref, w.value)
+		}
+	}
+}
+
+func TestDenseLimit(t *testing.T) {
+	file, err := ReadFile(filepath.Join("testdata", "hugedim.xlsx"), ReadOptions{Dense: true}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sheet1 claims A1:XFD1048576 but its cells fit in A1:B3, which is filled instead
+	if len(file.Data) != 6 {
+		t.Errorf("got %d cells, want the 6 of A1:B3", len(file.Data))
+	}
+	if len(file.Warnings) != 1 || file.Warnings[0].Sheet != "Sheet1" {
+		t.Errorf("warnings = %v, want one about Sheet1's dimension", file.Warnings)
+	}
+	// Sheet2's cells alone span the whole sheet
+	if len(file.SheetErrors) != 1 {
+		t.Errorf("sheet errors = %v, want one for Sheet2", file.SheetErrors)
+	}
+}
+
+// BenchmarkArchiveFind looks up every worksheet part of a 50-sheet workbook, through the
+// part index a Document builds and by scanning the archive's file list
+func BenchmarkArchiveFind(b *testing.B) {
+	parts := make([]string, 50)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+	}
+	for _, indexed := range []bool{true, false} {
+		name := "scan"
+		if indexed {
+			name = "index"
+		}
+		b.Run(name, func(b *testing.B) {
+			zipFile, err := zip.OpenReader(filepath.Join("testdata", "fifty.xlsx"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer zipFile.Close()
+			zipReader := archive{Reader: &zipFile.Reader}
+			if indexed {
+				zipReader = indexArchive(&zipFile.Reader)
+			}
+			b.ResetTimer()
+			for range b.N {
+				for _, part := range parts {
+					if zipReader.find(part) == nil {
+						b.Fatalf("%s not found", part)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkReadFile50Sheets(b *testing.B) {
+	for range b.N {
+		if _, err := ReadFile(filepath.Join("testdata", "fifty.xlsx"), ReadOptions{}, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package xlsx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// cfbMagic starts every OLE Compound File Binary container
+var cfbMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+var (
+	// ErrEncryptedWorkbook is returned for password-protected XLSX files, which are stored
+	// as an encrypted package inside a CFB container rather than as a plain zip
+	ErrEncryptedWorkbook = errors.New("file is encrypted/password-protected; remove the password in Excel and save again")
+	// ErrLegacyWorkbook is returned for CFB files that are not encrypted packages, i.e. .xls workbooks
+	ErrLegacyWorkbook = errors.New("file is a legacy .xls (BIFF) workbook, not .xlsx")
+	// ErrBinaryWorkbook is returned for .xlsb workbooks, zip packages like .xlsx whose parts
+	// hold binary (BIFF12) records instead of XML
+	ErrBinaryWorkbook = errors.New("file is an .xlsb binary workbook, which is not supported; save it as .xlsx in Excel")
+	// ErrNoSheets is returned for workbooks whose xl/workbook.xml lists no <sheet>, which
+	// Excel never saves, rather than reading them as a workbook without cells
+	ErrNoSheets = errors.New("workbook has no sheets: xl/workbook.xml lists none")
+)
+
+// binaryContentTypes are the content types of the workbook and worksheet parts of an .xlsb
+// package. Other .bin parts, such as the VBA project of an .xlsm, have types of their own.
+var binaryContentTypes = map[string]bool{
+	"application/vnd.ms-excel.sheet.binary.macroenabled.main": true,
+	"application/vnd.ms-excel.worksheet":                      true,
+}
+
+// detectBinaryWorkbook returns ErrBinaryWorkbook when [Content_Types].xml declares binary
+// workbook or worksheet parts, and nil otherwise
+func detectBinaryWorkbook(zipReader archive) error {
+	var types struct {
+		Default []struct {
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Default"`
+		Override []struct {
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}
+	if err := readXMLFromZip(zipReader, "[Content_Types].xml", &types); err != nil {
+		return nil
+	}
+	for _, d := range types.Default {
+		if binaryContentTypes[strings.ToLower(d.ContentType)] {
+			return ErrBinaryWorkbook
+		}
+	}
+	for _, o := range types.Override {
+		if binaryContentTypes[strings.ToLower(o.ContentType)] {
+			return ErrBinaryWorkbook
+		}
+	}
+	return nil
+}
+
+// detectCFB explains why a file that failed to open as a zip cannot be read, when it
+// is a CFB container. It returns nil for anything else so the zip error stands.
+func detectCFB(fileName string) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	return detectCFBReader(f)
+}
+
+// detectCFBReader is detectCFB for a file that is already open or held in memory
+func detectCFBReader(f io.ReaderAt) error {
+	// The 512-byte header holds the sector size and the first directory sector
+	header := make([]byte, 512)
+	if _, err := f.ReadAt(header, 0); err != nil || !bytes.Equal(header[:8], cfbMagic) {
+		return nil
+	}
+	sectorSize := int64(1) << binary.LittleEndian.Uint16(header[30:32])
+	firstDirSector := int64(binary.LittleEndian.Uint32(header[48:52]))
+	if sectorSize < 128 || sectorSize > 1<<16 {
+		return ErrLegacyWorkbook
+	}
+
+	// Sector N starts after the header, which takes one sector of sectorSize bytes
+	dir := make([]byte, sectorSize)
+	if _, err := f.ReadAt(dir, (firstDirSector+1)*sectorSize); err != nil && err != io.EOF {
+		return ErrLegacyWorkbook
+	}
+	// Each 128-byte directory entry starts with its UTF-16 name and the name length in bytes
+	for off := 0; off+128 <= len(dir); off += 128 {
+		nameLen := int(binary.LittleEndian.Uint16(dir[off+64 : off+66]))
+		if nameLen < 2 || nameLen > 64 {
+			continue
+		}
+		units := make([]uint16, nameLen/2-1) // Drop the terminating NUL
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(dir[off+2*i : off+2*i+2])
+		}
+		switch string(utf16.Decode(units)) {
+		case "EncryptedPackage", "EncryptionInfo":
+			return ErrEncryptedWorkbook
+		}
+	}
+	return ErrLegacyWorkbook
+}
@@ -0,0 +1,96 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// cfbFile builds the start of a CFB container: a header with 512-byte sectors and a
+// first directory sector holding entries of the given names
+func cfbFile(names ...string) []byte {
+	b := make([]byte, 1024)
+	copy(b, cfbMagic)
+	binary.LittleEndian.PutUint16(b[30:32], 9) // Sector size 1 << 9
+	binary.LittleEndian.PutUint32(b[48:52], 0) // First directory sector
+	for i, name := range names {
+		entry := b[512+128*i:]
+		units := utf16.Encode([]rune(name))
+		for j, u := range units {
+			binary.LittleEndian.PutUint16(entry[2*j:], u)
+		}
+		binary.LittleEndian.PutUint16(entry[64:66], uint16(2*len(units)+2)) // With the NUL
+	}
+	return b
+}
+
+// zipParts builds an archive holding the given parts
+func zipParts(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range parts {
+		part, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestContainerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file []byte
+		want error
+	}{
+		{"encrypted package", cfbFile("Root Entry", "EncryptionInfo", "EncryptedPackage"), ErrEncryptedWorkbook},
+		{"legacy xls", cfbFile("Root Entry", "Workbook", "\x05SummaryInformation"), ErrLegacyWorkbook},
+		{"xlsb", zipParts(t, map[string]string{
+			"[Content_Types].xml": `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+				`<Override PartName="/xl/workbook.bin" ContentType="application/vnd.ms-excel.sheet.binary.macroEnabled.main"/></Types>`,
+			"xl/workbook.bin": "\x83\x01\x00",
+		}), ErrBinaryWorkbook},
+		{"no sheets", zipParts(t, map[string]string{
+			"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheets/></workbook>`,
+		}), ErrNoSheets},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := OpenReader(bytes.NewReader(tt.file), int64(len(tt.file))); !errors.Is(err, tt.want) {
+				t.Errorf("OpenReader error = %v, want %v", err, tt.want)
+			}
+			// Open detects the container from the file on disk
+			path := filepath.Join(t.TempDir(), "book.xlsx")
+			if err := os.WriteFile(path, tt.file, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Open(path); !errors.Is(err, tt.want) {
+				t.Errorf("Open error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Neither a zip archive nor a CFB container: the zip error stands
+	garbage := []byte("not a workbook")
+	_, err := OpenReader(bytes.NewReader(garbage), int64(len(garbage)))
+	for _, sentinel := range []error{ErrEncryptedWorkbook, ErrLegacyWorkbook, ErrBinaryWorkbook, ErrNoSheets} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("OpenReader of a text file reported %v", err)
+		}
+	}
+	if err == nil {
+		t.Error("OpenReader read a text file")
+	}
+}
@@ -0,0 +1,98 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"fmt"
+	"strings"
+)
+
+// DocProps holds the document properties of a workbook: the core properties of
+// docProps/core.xml (title, author, dates) and the application properties of
+// docProps/app.xml. Dates are W3CDTF timestamps as stored, e.g. 2024-03-01T09:30:00Z.
+type DocProps struct {
+	Title          string `json:"title,omitempty"`
+	Subject        string `json:"subject,omitempty"`
+	Creator        string `json:"creator,omitempty"` // The author
+	Keywords       string `json:"keywords,omitempty"`
+	Description    string `json:"description,omitempty"`
+	Category       string `json:"category,omitempty"`
+	LastModifiedBy string `json:"last_modified_by,omitempty"`
+	Created        string `json:"created,omitempty"`
+	Modified       string `json:"modified,omitempty"`
+	Application    string `json:"application,omitempty"` // e.g. Microsoft Excel
+	AppVersion     string `json:"app_version,omitempty"` // e.g. 16.0300
+	Company        string `json:"company,omitempty"`
+}
+
+// coreProperties is the <cp:coreProperties> root of docProps/core.xml. Its elements come
+// from the Dublin Core namespaces, which are matched by local name only.
+type coreProperties struct {
+	Title          string `xml:"title"`
+	Subject        string `xml:"subject"`
+	Creator        string `xml:"creator"`
+	Keywords       string `xml:"keywords"`
+	Description    string `xml:"description"`
+	Category       string `xml:"category"`
+	LastModifiedBy string `xml:"lastModifiedBy"`
+	Created        string `xml:"created"`
+	Modified       string `xml:"modified"`
+}
+
+// appProperties is the <Properties> root of docProps/app.xml
+type appProperties struct {
+	Application string `xml:"Application"`
+	AppVersion  string `xml:"AppVersion"`
+	Company     string `xml:"Company"`
+}
+
+// ReadDocProps reads the document properties, found through the package relationships in
+// _rels/.rels, or at docProps/core.xml and docProps/app.xml when those are missing. A
+// workbook without the parts has empty properties.
+func ReadDocProps(zipReader *zip.Reader) (DocProps, error) {
+	return readDocProps(archive{Reader: zipReader})
+}
+
+func readDocProps(zipReader archive) (DocProps, error) {
+	corePath, appPath := "docProps/core.xml", "docProps/app.xml"
+	var rels Relationships
+	if zipReader.find("_rels/.rels") != nil {
+		if err := readXMLFromZip(zipReader, "_rels/.rels", &rels); err != nil {
+			return DocProps{}, err
+		}
+	}
+	for _, rel := range rels.Relationship {
+		switch {
+		case strings.HasSuffix(rel.Type, "/core-properties"):
+			corePath = resolvePartPath("", rel.Target)
+		case strings.HasSuffix(rel.Type, "/extended-properties"):
+			appPath = resolvePartPath("", rel.Target)
+		}
+	}
+
+	var props DocProps
+	if zipReader.find(corePath) != nil {
+		var core coreProperties
+		if err := readXMLFromZip(zipReader, corePath, &core); err != nil {
+			return DocProps{}, fmt.Errorf("core properties %s: %w", corePath, err)
+		}
+		props.Title = strings.TrimSpace(core.Title)
+		props.Subject = strings.TrimSpace(core.Subject)
+		props.Creator = strings.TrimSpace(core.Creator)
+		props.Keywords = strings.TrimSpace(core.Keywords)
+		props.Description = strings.TrimSpace(core.Description)
+		props.Category = strings.TrimSpace(core.Category)
+		props.LastModifiedBy = strings.TrimSpace(core.LastModifiedBy)
+		props.Created = strings.TrimSpace(core.Created)
+		props.Modified = strings.TrimSpace(core.Modified)
+	}
+	if zipReader.find(appPath) != nil {
+		var app appProperties
+		if err := readXMLFromZip(zipReader, appPath, &app); err != nil {
+			return DocProps{}, fmt.Errorf("application properties %s: %w", appPath, err)
+		}
+		props.Application = strings.TrimSpace(app.Application)
+		props.AppVersion = strings.TrimSpace(app.AppVersion)
+		props.Company = strings.TrimSpace(app.Company)
+	}
+	return props, nil
+}
@@ -0,0 +1,112 @@
+package xlsx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Byte order marks some producers write in front of XML parts
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// ReadBufferSize is the size of the buffer XML parts are read through, set by -read-buffer.
+// A larger buffer means fewer reads from the decompressor on large sheets, a smaller one
+// less memory per sheet read at the same time. Parts smaller than the buffer get one of
+// their own size, so the many small parts of a workbook take little memory either way.
+var ReadBufferSize = defaultReadBufferSize
+
+const (
+	defaultReadBufferSize = 128 << 10
+	MinReadBufferSize     = 4 << 10 // Leaves room for the head partReader peeks at
+	MaxReadBufferSize     = 64 << 20
+)
+
+// readBufferSize returns the buffer size for a part of partSize uncompressed bytes
+func readBufferSize(partSize uint64) int {
+	size := max(ReadBufferSize, MinReadBufferSize)
+	if partSize < uint64(size) {
+		size = max(int(partSize), MinReadBufferSize)
+	}
+	return size
+}
+
+// partReader returns the content of an XML part as UTF-8 read through a buffer of the
+// given size: without a leading byte order mark, and transcoded when the part is UTF-16
+// or its XML declaration names another encoding, e.g. windows-1252 or Shift_JIS. The
+// whole stream is converted rather than left to xml.Decoder.CharsetReader, so the chunks
+// of a sheet decoded in parallel, which lack the declaration, are UTF-8 too.
+func partReader(r io.Reader, size int) io.Reader {
+	buffered := bufio.NewReaderSize(r, size)
+	head, _ := buffered.Peek(256)
+	utf16 := func(order unicode.Endianness) io.Reader {
+		return transform.NewReader(buffered, unicode.UTF16(order, unicode.IgnoreBOM).NewDecoder())
+	}
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		buffered.Discard(len(utf8BOM))
+		head = head[len(utf8BOM):]
+	case bytes.HasPrefix(head, utf16LEBOM):
+		buffered.Discard(len(utf16LEBOM))
+		return utf16(unicode.LittleEndian)
+	case bytes.HasPrefix(head, utf16BEBOM):
+		buffered.Discard(len(utf16BEBOM))
+		return utf16(unicode.BigEndian)
+	case bytes.HasPrefix(head, []byte("<\x00?\x00")): // UTF-16 without a byte order mark
+		return utf16(unicode.LittleEndian)
+	case bytes.HasPrefix(head, []byte("\x00<\x00?")):
+		return utf16(unicode.BigEndian)
+	}
+	if label := declaredEncoding(head); label != "" {
+		if e, name := charset.Lookup(label); e != nil && name != "utf-8" {
+			return transform.NewReader(buffered, e.NewDecoder())
+		}
+	}
+	return buffered
+}
+
+// declaredEncoding returns the lower-cased encoding of the XML declaration at the start of
+// head, or "" when there is none
+func declaredEncoding(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("<?xml")) {
+		return ""
+	}
+	decl, _, _ := bytes.Cut(head, []byte("?>"))
+	_, value, found := bytes.Cut(decl, []byte("encoding"))
+	if !found {
+		return ""
+	}
+	value = bytes.TrimLeft(value, " \t\r\n=")
+	if len(value) == 0 || (value[0] != '"' && value[0] != '\'') {
+		return ""
+	}
+	end := bytes.IndexByte(value[1:], value[0])
+	if end < 0 {
+		return ""
+	}
+	return strings.ToLower(string(value[1 : end+1]))
+}
+
+// newXMLDecoder returns a decoder for a part read through partReader. The content is
+// UTF-8 by then, so CharsetReader only checks that the declared encoding is one
+// partReader knows, leaving the input as it is.
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if e, _ := charset.Lookup(label); e == nil {
+			return nil, fmt.Errorf("unsupported encoding %q", label)
+		}
+		return input, nil
+	}
+	return decoder
+}
@@ -0,0 +1,97 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPartEncodings(t *testing.T) {
+	tests := []struct {
+		file string
+		want map[string]string
+	}{
+		{"bom.xlsx", map[string]string{"A1": "café", "B1": "Grüße"}},
+		{"utf16le.xlsx", map[string]string{"A1": "café €", "B1": "Grüße €"}},
+		{"utf16be.xlsx", map[string]string{"A1": "café €", "B1": "Grüße €"}},
+		{"cp1252.xlsx", map[string]string{"A1": "café €", "B1": "Grüße €"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			file := readTestFile(t, tt.file, ReadOptions{})
+			cells := cellsByRef(file.Data)
+			for ref, want := range tt.want {
+				if got := cells[ref].SheetValue; got != want {
+					t.Errorf("%s = %q, want %q", ref, got, want)
+				}
+			}
+			if len(file.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", file.Warnings)
+			}
+		})
+	}
+}
+
+// writeBenchWorkbook writes a one-sheet workbook of rows by cols numeric cells and returns
+// its path and the size of its worksheet XML
+func writeBenchWorkbook(b *testing.B, rows, cols int) (string, int) {
+	b.Helper()
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for row := 1; row <= rows; row++ {
+		fmt.Fprintf(&sheet, `<row r="%d">`, row)
+		for col := int32(1); col <= int32(cols); col++ {
+			fmt.Fprintf(&sheet, `<c r="%s"><v>%d</v></c>`, FormatRef(col, int32(row)), row*cols+int(col))
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	parts := map[string]string{
+		"[Content_Types].xml":        `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="xml" ContentType="application/xml"/><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/></Types>`,
+		"xl/workbook.xml":            `<?xml version="1.0" encoding="UTF-8"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   sheet.String(),
+	}
+	path := filepath.Join(b.TempDir(), "bench.xlsx")
+	file, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		b.Fatal(err)
+	}
+	return path, sheet.Len()
+}
+
+// BenchmarkReadBufferSize reads a sheet of about 6MB of XML through read buffers of
+// several sizes, to compare with the default of ReadBufferSize
+func BenchmarkReadBufferSize(b *testing.B) {
+	path, size := writeBenchWorkbook(b, 20000, 10)
+	defer func(saved int) { ReadBufferSize = saved }(ReadBufferSize)
+	for _, bufferSize := range []int{MinReadBufferSize, 16 << 10, 128 << 10, 1 << 20, 4 << 20} {
+		b.Run(fmt.Sprintf("%dKB", bufferSize>>10), func(b *testing.B) {
+			ReadBufferSize = bufferSize
+			b.SetBytes(int64(size))
+			for range b.N {
+				if _, err := ReadFile(path, ReadOptions{SheetWorkers: 1}, false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// ExternalLink is a reference from the workbook to another file, stored in a part under
+// xl/externalLinks/. Formulas refer to it by its index, as in [1]Sheet1!A1.
+type ExternalLink struct {
+	Index        int      `json:"index"`                   // The n of [n] in formulas, counting from 1
+	Path         string   `json:"path"`                    // The link part, e.g. xl/externalLinks/externalLink1.xml
+	Kind         string   `json:"kind,omitempty"`          // workbook, dde or ole; unset when Missing
+	Target       string   `json:"target,omitempty"`        // The linked file as stored, e.g. file:///C:/Data/Prices.xlsx
+	Sheets       []string `json:"sheets,omitempty"`        // Sheet names of a linked workbook when it was last read
+	DefinedNames []string `json:"defined_names,omitempty"` // Names of the linked workbook used by formulas
+	Missing      bool     `json:"missing,omitempty"`       // Listed in workbook.xml, but the part is absent
+}
+
+// ExternalReference is an <externalReference> entry of workbook.xml
+type ExternalReference struct {
+	RID string `xml:"-"` // r:id, bound by namespace URI in UnmarshalXML
+}
+
+// UnmarshalXML picks out r:id in either relationships namespace, as for WorkbookSheet
+func (r *ExternalReference) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" && (attr.Name.Space == relationshipsNS || attr.Name.Space == strictRelationshipsNS) {
+			r.RID = attr.Value
+		}
+	}
+	return d.Skip()
+}
+
+// externalLinkPart is the <externalLink> root of an external link part, holding one of
+// a linked workbook, a DDE link or an OLE link
+type externalLinkPart struct {
+	ExternalBook *struct {
+		SheetNames struct {
+			SheetName []struct {
+				Val string `xml:"val,attr"`
+			} `xml:"sheetName"`
+		} `xml:"sheetNames"`
+		DefinedNames struct {
+			DefinedName []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"definedName"`
+		} `xml:"definedNames"`
+	} `xml:"externalBook"`
+	DDELink *struct {
+		Service string `xml:"ddeService,attr"`
+		Topic   string `xml:"ddeTopic,attr"`
+	} `xml:"ddeLink"`
+	OLELink *struct{} `xml:"oleLink"`
+}
+
+// ReadExternalLinks reads the external links of the workbook in the order of its
+// <externalReferences>, which gives their formula index. The linked files are not opened.
+func ReadExternalLinks(zipReader *zip.Reader, workbook *Workbook) ([]ExternalLink, error) {
+	return readExternalLinks(archive{Reader: zipReader}, workbook)
+}
+
+func readExternalLinks(zipReader archive, workbook *Workbook) ([]ExternalLink, error) {
+	refs := workbook.ExternalReferences.ExternalReference
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	rels, err := readWorkbookRels(zipReader)
+	if err != nil {
+		return nil, err
+	}
+	var links []ExternalLink
+	for i, ref := range refs {
+		link := ExternalLink{Index: i + 1}
+		if target, ok := rels[ref.RID]; ok {
+			link.Path = target
+		} else {
+			// No usable relationship, fall back to the conventional part name
+			link.Path = fmt.Sprintf("xl/externalLinks/externalLink%d.xml", i+1)
+		}
+		if zipReader.find(link.Path) == nil {
+			link.Missing = true
+			links = append(links, link)
+			continue
+		}
+		var part externalLinkPart
+		if err := readXMLFromZip(zipReader, link.Path, &part); err != nil {
+			return nil, fmt.Errorf("external link %s: %w", link.Path, err)
+		}
+		partRels, err := readPartRels(zipReader, link.Path)
+		if err != nil {
+			return nil, fmt.Errorf("external link %s: %w", link.Path, err)
+		}
+		switch {
+		case part.ExternalBook != nil:
+			link.Kind = "workbook"
+			for _, name := range part.ExternalBook.SheetNames.SheetName {
+				link.Sheets = append(link.Sheets, name.Val)
+			}
+			for _, name := range part.ExternalBook.DefinedNames.DefinedName {
+				link.DefinedNames = append(link.DefinedNames, name.Name)
+			}
+		case part.DDELink != nil:
+			link.Kind = "dde"
+			link.Target = part.DDELink.Service + "|" + part.DDELink.Topic
+		case part.OLELink != nil:
+			link.Kind = "ole"
+		}
+		if link.Target == "" {
+			// externalLinkPath, or xlExternalLinkPath/xlPathMissing when Excel could not
+			// resolve the file, and oleObject for OLE links
+			for _, rel := range partRels.Relationship {
+				kind := strings.ToLower(rel.Type)
+				if strings.Contains(kind, "externallinkpath") || strings.HasSuffix(kind, "/oleobject") {
+					link.Target = rel.Target
+					break
+				}
+			}
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
@@ -0,0 +1,266 @@
+package xlsx
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SkipSheet is returned by the emit function of ReadFileRows to skip the rest of the
+// sheet the row is on, e.g. once enough rows were read
+var SkipSheet = errors.New("skip sheet")
+
+// File holds everything read from one input file
+type File struct {
+	Data        []CellData
+	Metadata    *WorkbookMetadata // Only set when metadata was requested
+	Warnings    []Warning
+	SheetErrors []error      // Sheets that could not be read; the others are in Data
+	Stats       []ParseStats // One per sheet read, in the order the sheets finished
+}
+
+// ReadFile reads every sheet of an input file, concurrently, into Data: an XLSX workbook,
+// or a Parquet file written by this package. opts selects and filters the cells as
+// -range, -filter and the other CLI flags do. With withMetadata set, Metadata holds the
+// workbook's sidecar metadata. Sheets that cannot be read are in SheetErrors and the
+// problems found in the others in Warnings; only a file that cannot be opened fails.
+func ReadFile(fileName string, opts ReadOptions, withMetadata bool) (*File, error) {
+	return readInputFile(fileName, opts, withMetadata, nil, false)
+}
+
+// ReadFileSheets reads an input file like ReadFile, handing the cells of one sheet at a
+// time to emit instead of collecting them, so only a single sheet is held in memory.
+// An error from emit stops the read and is returned.
+func ReadFileSheets(fileName string, opts ReadOptions, withMetadata bool, emit func(cells []CellData) error) (*File, error) {
+	return readInputFile(fileName, opts, withMetadata, emit, false)
+}
+
+// ReadFileRows reads an input file like ReadFile, streaming the sheets and handing each
+// row's cells to emit as soon as the next row starts, so memory stays constant however
+// large the sheets are. Merged ranges are listed after a sheet's cells, so Merged is not
+// set, nor are the cells ExpandMerged, FlattenMerged, Dense or AutoFilter would add or
+// select. emit may return SkipSheet to skip the rest of the sheet; another error stops
+// the read and is returned. A Parquet file is read whole and handed over at once.
+func ReadFileRows(fileName string, opts ReadOptions, withMetadata bool, emit func(cells []CellData) error) (*File, error) {
+	return readInputFile(fileName, opts, withMetadata, emit, true)
+}
+
+// readXLSXFile opens an XLSX file and reads all of its sheets, plus the sidecar metadata if withMetadata is set.
+// With emit set, the sheets are read one at a time and handed to emit instead of being collected in Data;
+// with byRow also set, they are streamed and emit gets one row at a time, and may return SkipSheet.
+func readXLSXFile(fileName string, opts ReadOptions, withMetadata bool, emit func([]CellData) error, byRow bool) (*File, error) {
+	// Open the XLSX file and read the workbook, shared strings and styles
+	open := Open
+	if opts.Recover {
+		open = OpenRecover
+	}
+	doc, err := open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer doc.Close()
+	r, workbook := doc.zipReader, doc.Workbook
+
+	// With -range or -table, only the sheet it names needs to be read
+	var sheetRange SheetRange
+	restricted := opts.Range != "" || opts.Table != ""
+	switch {
+	case opts.Table != "":
+		tables, err := readTables(r, workbook)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tables: %w", err)
+		}
+		table, err := findTable(tables, opts.Table)
+		if err != nil {
+			return nil, err
+		}
+		sheetRange = SheetRange{Sheet: table.Sheet, CellRange: table.Range}
+	case opts.Range != "":
+		if sheetRange, err = resolveRange(opts.Range, workbook.definedNames()); err != nil {
+			return nil, err
+		}
+	}
+	if sheetRange.Sheet != "" {
+		if _, err := findSheet(workbook, sheetRange.Sheet); err != nil {
+			return nil, err
+		}
+		var sheets []WorkbookSheet
+		for _, sheet := range workbook.Sheets.Sheet {
+			if sheet.Name == sheetRange.Sheet {
+				sheets = append(sheets, sheet)
+			}
+		}
+		workbook.Sheets.Sheet = sheets
+	}
+
+	// With -active-only, only the sheet that was open when the workbook was saved
+	if opts.ActiveOnly && len(workbook.Sheets.Sheet) > 0 {
+		if sheetRange.Sheet != "" && sheetRange.Sheet != workbook.ActiveSheet {
+			return nil, fmt.Errorf("the selected range is on sheet %s but the active sheet is %s", sheetRange.Sheet, workbook.ActiveSheet)
+		}
+		active, _ := findSheet(workbook, workbook.ActiveSheet)
+		workbook.Sheets.Sheet = []WorkbookSheet{active}
+	}
+
+	// Collect the warnings of this file, still passing them on to the caller's Warn
+	result := &File{}
+	var warnMu sync.Mutex
+	callerWarn := opts.Warn
+	opts.Warn = func(w Warning) {
+		warnMu.Lock()
+		result.Warnings = append(result.Warnings, w)
+		warnMu.Unlock()
+		if callerWarn != nil {
+			callerWarn(w)
+		}
+	}
+	callerStats := opts.Stats
+	opts.Stats = func(s ParseStats) {
+		warnMu.Lock()
+		result.Stats = append(result.Stats, s)
+		warnMu.Unlock()
+		if callerStats != nil {
+			callerStats(s)
+		}
+	}
+
+	if doc.Recovery != nil {
+		lost := ""
+		if len(doc.Recovery.Lost) > 0 {
+			lost = "; lost " + strings.Join(doc.Recovery.Lost, ", ")
+		}
+		opts.warn("", "", "damaged zip archive: recovered %d parts from the local file headers%s", len(doc.Recovery.Parts), lost)
+	}
+
+	// sheetStart is set when data begins with the first row of its sheets, their header
+	filter := func(data []CellData, sheetStart bool) []CellData {
+		if restricted {
+			data = filterRange(data, sheetRange)
+		}
+		if opts.Filter != nil {
+			data = filterRows(data, opts.Filter, opts.KeepHeaderRow && sheetStart)
+		}
+		if opts.Columns != nil {
+			data = filterColumns(data, opts.Columns)
+		}
+		return data
+	}
+
+	if emit != nil && byRow {
+		// Each row is handed on as soon as the next one starts, so only a single row is held
+		opts = opts.forWorkbook(workbook)
+		var row []CellData
+		var emitErr error
+		firstRow := false
+		flush := func() error {
+			cells := filter(row, firstRow)
+			row, firstRow = row[:0], false
+			if len(cells) == 0 {
+				return nil
+			}
+			emitErr = emit(cells)
+			return emitErr
+		}
+		for _, sheet := range workbook.Sheets.Sheet {
+			firstRow = true
+			_, err := streamSheet(r, sheet.Name, sheet.Path, doc.SharedStrings, doc.Styles, opts, func(d CellData) error {
+				if len(row) > 0 && d.RowNumber != row[0].RowNumber {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+				row = append(row, d)
+				return nil
+			})
+			if err == nil {
+				err = flush()
+			}
+			if errors.Is(err, SkipSheet) {
+				err, emitErr = nil, nil
+				row = row[:0]
+			}
+			if emitErr != nil {
+				return nil, emitErr
+			}
+			if err != nil {
+				row = row[:0] // The rest of a sheet that failed midway is not written
+				result.SheetErrors = append(result.SheetErrors, fmt.Errorf("sheet %s: %w", sheet.Name, err))
+			}
+		}
+	} else if emit != nil {
+		// One sheet at a time, so only a single sheet is held in memory
+		opts = opts.forWorkbook(workbook)
+		for _, sheet := range workbook.Sheets.Sheet {
+			cells, err := readSheetData(r, sheet.Name, sheet.Path, doc.SharedStrings, doc.Styles, opts)
+			if err != nil {
+				result.SheetErrors = append(result.SheetErrors, fmt.Errorf("sheet %s: %w", sheet.Name, err))
+				continue
+			}
+			if err := emit(filter(cells, true)); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Process sheets concurrently
+		var data []CellData
+		var wg sync.WaitGroup
+		result.SheetErrors = processSheetsConcurrently(r, workbook, doc.SharedStrings, doc.Styles, opts, &data, &wg)
+		result.Data = filter(data, true)
+	}
+
+	if withMetadata {
+		if result.Metadata, err = readWorkbookMetadata(r, workbook, fileName); err != nil {
+			return nil, fmt.Errorf("failed to read metadata: %w", err)
+		}
+		if opts.Styles {
+			result.Metadata.Styles = doc.Styles.Catalog()
+		}
+	}
+	return result, nil
+}
+
+// readInputFile reads an input file: an XLSX workbook, or a Parquet file written by this
+// package, which has no workbook metadata. Range and Filter apply to both. With emit set
+// the cells are handed to it rather than returned in Data, as with readXLSXFile. A Parquet
+// file is read whole, so byRow only applies to workbooks.
+func readInputFile(fileName string, opts ReadOptions, withMetadata bool, emit func([]CellData) error, byRow bool) (*File, error) {
+	if !strings.EqualFold(filepath.Ext(fileName), ".parquet") {
+		return readXLSXFile(fileName, opts, withMetadata, emit, byRow)
+	}
+	if opts.Table != "" || opts.AutoFilter {
+		return nil, fmt.Errorf("-table and -range %s need a workbook: %s is a Parquet file", AutoFilterRange, fileName)
+	}
+	data, err := readParquet(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxColumns > 0 {
+		data = slices.DeleteFunc(data, func(d CellData) bool { return d.ColumnNumber > opts.MaxColumns })
+	}
+	if opts.Sample > 1 {
+		data = sampleRows(data, opts.Sample)
+	}
+	if opts.Range != "" {
+		sheetRange, err := resolveRange(opts.Range, nil) // Defined names only exist in workbooks
+		if err != nil {
+			return nil, err
+		}
+		data = filterRange(data, sheetRange)
+	}
+	if opts.Filter != nil {
+		data = filterRows(data, opts.Filter, opts.KeepHeaderRow)
+	}
+	if opts.Columns != nil {
+		data = filterColumns(data, opts.Columns)
+	}
+	if emit != nil {
+		if err := emit(data); err != nil && !errors.Is(err, SkipSheet) {
+			return nil, err
+		}
+		return &File{}, nil
+	}
+	return &File{Data: data}, nil
+}
@@ -0,0 +1,66 @@
+package xlsx
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// readTestFile reads a workbook of testdata, failing the test if it cannot be read
+func readTestFile(t *testing.T, name string, opts ReadOptions) *File {
+	t.Helper()
+	file, err := ReadFile(filepath.Join("testdata", name), opts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range file.SheetErrors {
+		t.Errorf("sheet error: %v", err)
+	}
+	return file
+}
+
+// cellsByRef indexes cells by their reference, e.g. B2
+func cellsByRef(data []CellData) map[string]CellData {
+	cells := make(map[string]CellData, len(data))
+	for _, d := range data {
+		cells[FormatRef(d.ColumnNumber, d.RowNumber)] = d
+	}
+	return cells
+}
+
+func TestMergedCells(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   ReadOptions
+		merged map[string]string // Cell -> value of the cells inside A1:B2
+		cells  int
+	}{
+		{"cells of the sheet", ReadOptions{}, map[string]string{"A1": "Merged", "B1": ""}, 5},
+		{"expanded", ReadOptions{ExpandMerged: true}, map[string]string{"A1": "Merged", "B1": "Merged", "A2": "Merged", "B2": "Merged"}, 7},
+		{"flattened", ReadOptions{FlattenMerged: true}, map[string]string{"A1": "Merged", "B1": "Merged"}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := readTestFile(t, "merged.xlsx", tt.opts)
+			if len(file.Data) != tt.cells {
+				t.Errorf("got %d cells, want %d", len(file.Data), tt.cells)
+			}
+			cells := cellsByRef(file.Data)
+			for ref, value := range tt.merged {
+				d, ok := cells[ref]
+				switch {
+				case !ok:
+					t.Errorf("%s is missing", ref)
+				case !d.Merged || d.MergedRange != "A1:B2":
+					t.Errorf("%s: merged %v, range %q, want merged in A1:B2", ref, d.Merged, d.MergedRange)
+				case d.SheetValue != value:
+					t.Errorf("%s = %q, want %q", ref, d.SheetValue, value)
+				}
+			}
+			for _, ref := range []string{"C1", "C2", "A3"} {
+				if d := cells[ref]; d.Merged || d.MergedRange != "" {
+					t.Errorf("%s is outside A1:B2 but flagged merged in %q", ref, d.MergedRange)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package xlsx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RowFilter keeps the rows whose value in Column satisfies the comparison, e.g. C=Active
+type RowFilter struct {
+	Column int32
+	Op     string // "=", "!=" or "~" (contains)
+	Value  string
+}
+
+// ParseRowFilter parses a -filter expression: column letters, an operator and a value
+func ParseRowFilter(expr string) (*RowFilter, error) {
+	rest := strings.TrimLeft(expr, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+	col, err := ColumnToIndex(strings.ToUpper(expr[:len(expr)-len(rest)]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: must start with a column such as C", expr)
+	}
+	for _, op := range []string{"!=", "=", "~"} { // "!=" before "=" so it is not read as "!" + "="
+		if value, ok := strings.CutPrefix(rest, op); ok {
+			return &RowFilter{Column: col, Op: op, Value: value}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid filter %q: expected =, != or ~ after the column", expr)
+}
+
+// match reports whether a row whose filter column holds value is kept
+func (f *RowFilter) match(value string) bool {
+	switch f.Op {
+	case "=":
+		return value == f.Value
+	case "!=":
+		return value != f.Value
+	case "~":
+		return strings.Contains(value, f.Value)
+	}
+	return false
+}
+
+// filterRows keeps the cells of the rows that match f. A row without a cell in the
+// filter column is compared as an empty value. With keepHeader set, the first row of
+// each sheet is kept whatever its value, as the header of the rows that match.
+func filterRows(data []CellData, f *RowFilter, keepHeader bool) []CellData {
+	type rowKey struct {
+		sheet string
+		row   int32
+	}
+	// First pass: the filter column's value of every row, as the row's cells may come in any order
+	values := make(map[rowKey]string)
+	headers := make(map[string]int32) // First row of each sheet
+	for _, d := range data {
+		if d.ColumnNumber == f.Column {
+			values[rowKey{d.SheetName, d.RowNumber}] = d.SheetValue
+		}
+		if first, ok := headers[d.SheetName]; keepHeader && (!ok || d.RowNumber < first) {
+			headers[d.SheetName] = d.RowNumber
+		}
+	}
+	kept := data[:0]
+	for _, d := range data {
+		if first, ok := headers[d.SheetName]; ok && d.RowNumber == first {
+			kept = append(kept, d)
+		} else if f.match(values[rowKey{d.SheetName, d.RowNumber}]) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// ParseColumns parses a -columns list such as "A,C,F" or "A:C,F" into a set of column numbers
+func ParseColumns(spec string) (map[int32]bool, error) {
+	columns := make(map[int32]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		first, last, isRange := strings.Cut(part, ":")
+		if !isRange {
+			last = first
+		}
+		from, err := ColumnToIndex(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid columns %q: %w", spec, err)
+		}
+		to, err := ColumnToIndex(last)
+		if err != nil {
+			return nil, fmt.Errorf("invalid columns %q: %w", spec, err)
+		}
+		if from > to {
+			return nil, fmt.Errorf("invalid columns %q: %s comes after %s", spec, first, last)
+		}
+		for col := from; col <= to; col++ {
+			columns[col] = true
+		}
+	}
+	return columns, nil
+}
+
+// filterColumns keeps only the cells in the selected columns, reusing the backing array of data
+func filterColumns(data []CellData, columns map[int32]bool) []CellData {
+	kept := data[:0]
+	for _, d := range data {
+		if columns[d.ColumnNumber] {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// sampleRows keeps every n-th row of each sheet, counting rows in order of first
+// appearance and starting with the first, like -sample does while decoding a workbook
+func sampleRows(data []CellData, n int) []CellData {
+	type rowKey struct {
+		sheet string
+		row   int32
+	}
+	sampled := make(map[rowKey]bool)
+	seen := make(map[string]int) // Rows seen per sheet
+	kept := data[:0]
+	for _, d := range data {
+		key := rowKey{d.SheetName, d.RowNumber}
+		keep, ok := sampled[key]
+		if !ok {
+			keep = seen[d.SheetName]%n == 0
+			sampled[key] = keep
+			seen[d.SheetName]++
+		}
+		if keep {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,178 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// errStopIteration aborts the sheet decode when the range loop over Cells exits early, or
+// once ReadCell has found its cell
+var errStopIteration = errors.New("iteration stopped")
+
+// findSheet returns the workbook entry of the sheet with the given name
+func findSheet(workbook *Workbook, sheetName string) (WorkbookSheet, error) {
+	for _, sheet := range workbook.Sheets.Sheet {
+		if sheet.Name == sheetName {
+			return sheet, nil
+		}
+	}
+	return WorkbookSheet{}, fmt.Errorf("sheet %q not found in workbook", sheetName)
+}
+
+// Cells lazily yields the cells of the named sheet in document order:
+//
+//	for cell, err := range Cells(z, "Sheet1") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// It is built on StreamSheet, so memory stays constant however large the sheet is and
+// breaking out of the loop stops decoding. Like StreamSheet, merged cells are not flagged.
+// An error is yielded once, as the last element.
+func Cells(zipReader *zip.Reader, sheetName string) iter.Seq2[CellData, error] {
+	return func(yield func(CellData, error) bool) {
+		workbook, err := ReadWorkbook(zipReader)
+		if err != nil {
+			yield(CellData{}, fmt.Errorf("failed to read workbook: %w", err))
+			return
+		}
+		sheet, err := findSheet(workbook, sheetName)
+		if err != nil {
+			yield(CellData{}, err)
+			return
+		}
+		sharedStrings, err := ReadSharedStrings(zipReader)
+		if err != nil {
+			yield(CellData{}, fmt.Errorf("failed to read shared strings: %w", err))
+			return
+		}
+		styles, err := ReadStyles(zipReader)
+		if err != nil {
+			yield(CellData{}, fmt.Errorf("failed to read styles: %w", err))
+			return
+		}
+
+		opts := ReadOptions{}.forWorkbook(workbook)
+		err = StreamSheet(zipReader, sheet.Name, sheet.Path, sharedStrings, styles, opts, func(d CellData) error {
+			if !yield(d, nil) {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			yield(CellData{}, err)
+		}
+	}
+}
+
+// ReadCell returns the cell at ref (e.g. "B7" or "$B$7") of the named sheet. The sheet is
+// only decoded up to the row holding the cell, so a cell near the top is read much faster
+// than with ReadSheetData. A position with no cell in the sheet gives an empty CellData at
+// that position. Like StreamSheet, merged cells are not flagged.
+func ReadCell(zipReader *zip.Reader, sheetName, ref string) (CellData, error) {
+	col, row, err := parseCellRef(ref)
+	if err != nil {
+		return CellData{}, err
+	}
+	workbook, err := ReadWorkbook(zipReader)
+	if err != nil {
+		return CellData{}, fmt.Errorf("failed to read workbook: %w", err)
+	}
+	sheet, err := findSheet(workbook, sheetName)
+	if err != nil {
+		return CellData{}, err
+	}
+	sharedStrings, err := ReadSharedStrings(zipReader)
+	if err != nil {
+		return CellData{}, fmt.Errorf("failed to read shared strings: %w", err)
+	}
+	styles, err := ReadStyles(zipReader)
+	if err != nil {
+		return CellData{}, fmt.Errorf("failed to read styles: %w", err)
+	}
+	return readCell(archive{Reader: zipReader}, sheet, sharedStrings, styles, ReadOptions{}.forWorkbook(workbook), col, row)
+}
+
+// ReadHeaders returns the values of the first row of the named sheet that holds cells,
+// its header row in most sheets, by position: headers[0] is column A, and columns without
+// a cell are "". Decoding stops after that row, so this is fast however large the sheet.
+func ReadHeaders(zipReader *zip.Reader, sheetName string) ([]string, error) {
+	workbook, err := ReadWorkbook(zipReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workbook: %w", err)
+	}
+	sheet, err := findSheet(workbook, sheetName)
+	if err != nil {
+		return nil, err
+	}
+	sharedStrings, err := ReadSharedStrings(zipReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared strings: %w", err)
+	}
+	styles, err := ReadStyles(zipReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read styles: %w", err)
+	}
+	return readHeaders(archive{Reader: zipReader}, sheet, sharedStrings, styles, ReadOptions{}.forWorkbook(workbook))
+}
+
+// readHeaders streams the sheet until a cell of a second row is decoded
+func readHeaders(zipReader archive, sheet WorkbookSheet, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions) ([]string, error) {
+	var headerRow int32
+	var headers []string
+	_, err := streamSheet(zipReader, sheet.Name, sheet.Path, sharedStrings, styles, opts, func(d CellData) error {
+		if headerRow == 0 {
+			headerRow = d.RowNumber
+		}
+		if d.RowNumber != headerRow {
+			return errStopIteration
+		}
+		if d.ColumnNumber < 1 {
+			return nil
+		}
+		if int(d.ColumnNumber) > len(headers) {
+			headers = append(headers, make([]string, int(d.ColumnNumber)-len(headers))...)
+		}
+		headers[d.ColumnNumber-1] = d.SheetValue
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// parseCellRef parses the reference of a single cell, ignoring absolute markers ($)
+func parseCellRef(ref string) (col, row int32, err error) {
+	col, row, err = ParseRef(strings.ToUpper(strings.ReplaceAll(ref, "$", "")))
+	if err == nil && (col == 0 || row == 0) {
+		err = fmt.Errorf("cell reference %q: needs a column and a row, e.g. B7", ref)
+	}
+	return col, row, err
+}
+
+// readCell streams the sheet until the cell at col, row is decoded. Rows are stored in
+// ascending order, so the first cell past the row means the position is empty. A sheet
+// with rows out of order, which Excel never writes, may hold the cell further down.
+func readCell(zipReader archive, sheet WorkbookSheet, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions, col, row int32) (CellData, error) {
+	cell := CellData{SheetName: sheet.Name, RowNumber: row, ColumnNumber: col}
+	_, err := streamSheet(zipReader, sheet.Name, sheet.Path, sharedStrings, styles, opts, func(d CellData) error {
+		if d.RowNumber == row && d.ColumnNumber == col {
+			cell = d
+			return errStopIteration
+		}
+		if d.RowNumber > row {
+			return errStopIteration
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return CellData{}, err
+	}
+	return cell, nil
+}
@@ -0,0 +1,423 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ColumnWidth is a <cols><col> definition covering columns Min through Max
+type ColumnWidth struct {
+	Min         int32   `json:"min"`
+	Max         int32   `json:"max"`
+	Width       float64 `json:"width"`
+	CustomWidth bool    `json:"custom_width,omitempty"`
+	Hidden      bool    `json:"hidden,omitempty"`
+}
+
+// RowHeight is a row carrying an explicit ht attribute
+type RowHeight struct {
+	Row          int32   `json:"row"`
+	Height       float64 `json:"height"`
+	CustomHeight bool    `json:"custom_height,omitempty"`
+	Hidden       bool    `json:"hidden,omitempty"`
+}
+
+// SheetLayout holds the column widths and row heights of a sheet
+type SheetLayout struct {
+	Columns []ColumnWidth `json:"columns,omitempty"`
+	Rows    []RowHeight   `json:"rows,omitempty"`
+}
+
+// TabColor is a sheet's <tabColor>: an ARGB value, or a theme or legacy palette index
+// with an optional tint. Font and fill colours of the style catalog take the same form.
+type TabColor struct {
+	RGB     string  `json:"rgb,omitempty" xml:"rgb,attr"` // e.g. FFFF0000 for red
+	Theme   *int    `json:"theme,omitempty" xml:"theme,attr"`
+	Indexed *int    `json:"indexed,omitempty" xml:"indexed,attr"`
+	Tint    float64 `json:"tint,omitempty" xml:"tint,attr"`
+}
+
+// SheetProperties holds the <sheetPr> of a sheet
+type SheetProperties struct {
+	CodeName string    `json:"code_name,omitempty"` // Name of the sheet in VBA code
+	TabColor *TabColor `json:"tab_color,omitempty"`
+}
+
+// SheetMetadata describes one sheet in the metadata sidecar
+type SheetMetadata struct {
+	Name       string           `json:"name"`
+	Path       string           `json:"path"`
+	Layout     *SheetLayout     `json:"layout,omitempty"`
+	Properties *SheetProperties `json:"properties,omitempty"`  // Unset when the sheet has no <sheetPr>
+	Missing    bool             `json:"missing,omitempty"`     // The worksheet part is not in the archive, see MissingSheetError
+	Tables     []Table          `json:"tables,omitempty"`      // Excel tables (ListObjects) on the sheet
+	AutoFilter string           `json:"auto_filter,omitempty"` // Range of the sheet's filter buttons, e.g. A1:F100
+
+	HasDrawings bool `json:"has_drawings"` // Images, shapes or charts anchored on the sheet
+	HasCharts   bool `json:"has_charts"`
+}
+
+// WorkbookMetadata describes one input workbook in the metadata sidecar
+type WorkbookMetadata struct {
+	SourceFile     string          `json:"source_file"`
+	ActiveTab      int             `json:"active_tab"`           // Index of the active sheet in the workbook's sheet list
+	ActiveSheet    string          `json:"active_sheet"`         // Name of the active sheet
+	FullCalcOnLoad bool            `json:"full_calc_on_load"`    // Recalculated on open, cached formula results may be outdated
+	Properties     *DocProps       `json:"properties,omitempty"` // Author, title, dates and application; unset when the workbook has none
+	Styles         []CellStyle     `json:"styles,omitempty"`     // Style catalog, with -styles
+	ExternalLinks  []ExternalLink  `json:"external_links,omitempty"`
+	Sheets         []SheetMetadata `json:"sheets"`
+}
+
+// ReadSheetLayout streams a worksheet part and collects its <col> widths and row heights
+func ReadSheetLayout(zipReader *zip.Reader, fileName string) (*SheetLayout, error) {
+	layout, _, err := readSheetOutline(archive{Reader: zipReader}, fileName)
+	return layout, err
+}
+
+// readSheetOutline streams a worksheet part once for both ReadSheetLayout and
+// ReadAutoFilter, as each would otherwise read the whole sheet for a few elements
+func readSheetOutline(zipReader archive, fileName string) (*SheetLayout, string, error) {
+	file := zipReader.find(fileName)
+	if file == nil {
+		return nil, "", fmt.Errorf("sheet %s not found", fileName)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	var layout SheetLayout
+	var autoFilter string
+	inCustomView := false
+	decoder := newXMLDecoder(partReader(f, readBufferSize(file.UncompressedSize64)))
+	for {
+		t, err := decoder.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, "", err
+		}
+		if token, ok := t.(xml.EndElement); ok && token.Name.Local == "customSheetView" {
+			inCustomView = false
+		}
+		token, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch token.Name.Local {
+		case "customSheetView":
+			inCustomView = true
+		case "autoFilter":
+			for _, attr := range token.Attr {
+				if attr.Name.Local == "ref" && !inCustomView {
+					autoFilter = strings.ReplaceAll(attr.Value, "$", "")
+				}
+			}
+		case "col":
+			var col ColumnWidth
+			for _, attr := range token.Attr {
+				switch attr.Name.Local {
+				case "min":
+					col.Min = parseInt32(attr.Value)
+				case "max":
+					col.Max = parseInt32(attr.Value)
+				case "width":
+					col.Width, _ = strconv.ParseFloat(attr.Value, 64)
+				case "customWidth":
+					col.CustomWidth = parseXMLBool(attr.Value)
+				case "hidden":
+					col.Hidden = parseXMLBool(attr.Value)
+				}
+			}
+			layout.Columns = append(layout.Columns, col)
+		case "row":
+			var row RowHeight
+			hasHeight := false
+			for _, attr := range token.Attr {
+				switch attr.Name.Local {
+				case "r":
+					row.Row = parseInt32(attr.Value)
+				case "ht":
+					row.Height, _ = strconv.ParseFloat(attr.Value, 64)
+					hasHeight = true
+				case "customHeight":
+					row.CustomHeight = parseXMLBool(attr.Value)
+				case "hidden":
+					row.Hidden = parseXMLBool(attr.Value)
+				}
+			}
+			if hasHeight || row.Hidden {
+				layout.Rows = append(layout.Rows, row)
+			}
+		}
+	}
+	return &layout, autoFilter, nil
+}
+
+// SheetStats counts the <row> and <c> elements of a worksheet part without decoding any
+// value, for sizing a sheet before reading it. Empty styled cells are counted too, as
+// they are <c> elements.
+func SheetStats(zipReader *zip.Reader, fileName string) (rows int32, cells int64, err error) {
+	return sheetStats(archive{Reader: zipReader}, fileName)
+}
+
+func sheetStats(zipReader archive, fileName string) (rows int32, cells int64, err error) {
+	file := zipReader.find(fileName)
+	if file == nil {
+		return 0, 0, fmt.Errorf("sheet %s not found", fileName)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	decoder := newXMLDecoder(partReader(f, readBufferSize(file.UncompressedSize64)))
+	for {
+		t, err := decoder.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				return rows, cells, nil
+			}
+			return 0, 0, err
+		}
+		if token, ok := t.(xml.StartElement); ok {
+			switch token.Name.Local {
+			case "row":
+				rows++
+			case "c":
+				cells++
+			}
+		}
+	}
+}
+
+// ReadAutoFilter returns the range of a worksheet's <autoFilter>, e.g. "A1:F100", or ""
+// when the sheet has no filter. Tables carry their own filters in their table parts,
+// see ReadSheetTables; this is the sheet's single filter outside any table. The filters
+// saved with custom views, inside <customSheetView>, are not the sheet's and are skipped.
+func ReadAutoFilter(zipReader *zip.Reader, fileName string) (string, error) {
+	_, autoFilter, err := readSheetOutline(archive{Reader: zipReader}, fileName)
+	return autoFilter, err
+}
+
+// partRelsPath returns the .rels part holding the relationships of the given part,
+// e.g. xl/worksheets/_rels/sheet1.xml.rels for xl/worksheets/sheet1.xml
+func partRelsPath(part string) string {
+	return path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+}
+
+// readPartRels reads the relationships of a part. A part without a .rels part has none.
+func readPartRels(zipReader archive, part string) (*Relationships, error) {
+	var rels Relationships
+	relsPath := partRelsPath(part)
+	if zipReader.find(relsPath) == nil {
+		return &rels, nil
+	}
+	if err := readXMLFromZip(zipReader, relsPath, &rels); err != nil {
+		return nil, err
+	}
+	return &rels, nil
+}
+
+// ReadSheetDrawings reports whether a worksheet has a drawing and whether any of its
+// drawings holds a chart, following the sheet's rels to the drawing parts' own rels.
+// Relationship types are matched on their last segment so Strict OOXML URIs match too.
+func ReadSheetDrawings(zipReader *zip.Reader, sheetPath string) (hasDrawings, hasCharts bool, err error) {
+	return readSheetDrawings(archive{Reader: zipReader}, sheetPath)
+}
+
+func readSheetDrawings(zipReader archive, sheetPath string) (hasDrawings, hasCharts bool, err error) {
+	rels, err := readPartRels(zipReader, sheetPath)
+	if err != nil {
+		return false, false, err
+	}
+	for _, rel := range rels.Relationship {
+		if !strings.HasSuffix(rel.Type, "/drawing") {
+			continue
+		}
+		hasDrawings = true
+		if hasCharts {
+			continue
+		}
+		drawing := resolvePartPath(path.Dir(sheetPath), rel.Target)
+		drawingRels, err := readPartRels(zipReader, drawing)
+		if err != nil {
+			return false, false, fmt.Errorf("drawing %s: %w", drawing, err)
+		}
+		for _, dr := range drawingRels.Relationship {
+			if strings.HasSuffix(dr.Type, "/chart") {
+				hasCharts = true
+				break
+			}
+		}
+	}
+	return hasDrawings, hasCharts, nil
+}
+
+// ReadSheetProperties reads the <sheetPr> of a worksheet part. It comes first in the part,
+// so decoding stops at <sheetData> without reading the cells. It returns nil when the
+// sheet has no properties.
+func ReadSheetProperties(zipReader *zip.Reader, fileName string) (*SheetProperties, error) {
+	return readSheetProperties(archive{Reader: zipReader}, fileName)
+}
+
+func readSheetProperties(zipReader archive, fileName string) (*SheetProperties, error) {
+	file := zipReader.find(fileName)
+	if file == nil {
+		return nil, fmt.Errorf("sheet %s not found", fileName)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var props *SheetProperties
+	decoder := newXMLDecoder(partReader(f, MinReadBufferSize)) // Only the start of the part is read
+	for {
+		t, err := decoder.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				return props, nil
+			}
+			return nil, err
+		}
+		token, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch token.Name.Local {
+		case "sheetPr":
+			props = &SheetProperties{}
+			for _, attr := range token.Attr {
+				if attr.Name.Local == "codeName" {
+					props.CodeName = attr.Value
+				}
+			}
+		case "tabColor":
+			if props == nil {
+				continue
+			}
+			color := &TabColor{}
+			for _, attr := range token.Attr {
+				switch attr.Name.Local {
+				case "rgb":
+					color.RGB = attr.Value
+				case "theme":
+					if n, err := strconv.Atoi(attr.Value); err == nil {
+						color.Theme = &n
+					}
+				case "indexed":
+					if n, err := strconv.Atoi(attr.Value); err == nil {
+						color.Indexed = &n
+					}
+				case "tint":
+					color.Tint, _ = strconv.ParseFloat(attr.Value, 64)
+				}
+			}
+			props.TabColor = color
+		case "sheetData":
+			return props, nil // <sheetPr> only appears before the cells
+		}
+	}
+}
+
+// ReadWorkbookMetadata gathers the sidecar metadata of every sheet in the workbook
+func ReadWorkbookMetadata(zipReader *zip.Reader, workbook *Workbook, sourceFile string) (*WorkbookMetadata, error) {
+	return readWorkbookMetadata(archive{Reader: zipReader}, workbook, sourceFile)
+}
+
+func readWorkbookMetadata(zipReader archive, workbook *Workbook, sourceFile string) (*WorkbookMetadata, error) {
+	meta := &WorkbookMetadata{SourceFile: sourceFile, ActiveTab: workbook.ActiveTab, ActiveSheet: workbook.ActiveSheet, FullCalcOnLoad: workbook.FullCalcOnLoad}
+	props, err := readDocProps(zipReader)
+	if err != nil {
+		return nil, err
+	}
+	if props != (DocProps{}) {
+		meta.Properties = &props
+	}
+	if meta.ExternalLinks, err = readExternalLinks(zipReader, workbook); err != nil {
+		return nil, err
+	}
+	for _, sheet := range workbook.Sheets.Sheet {
+		if zipReader.find(sheet.Path) == nil {
+			meta.Sheets = append(meta.Sheets, SheetMetadata{Name: sheet.Name, Path: sheet.Path, Missing: true})
+			continue
+		}
+		layout, autoFilter, err := readSheetOutline(zipReader, sheet.Path)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", sheet.Name, err)
+		}
+		hasDrawings, hasCharts, err := readSheetDrawings(zipReader, sheet.Path)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", sheet.Name, err)
+		}
+		props, err := readSheetProperties(zipReader, sheet.Path)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", sheet.Name, err)
+		}
+		tables, err := readSheetTables(zipReader, sheet)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", sheet.Name, err)
+		}
+		meta.Sheets = append(meta.Sheets, SheetMetadata{
+			Name:        sheet.Name,
+			Path:        sheet.Path,
+			Layout:      layout,
+			Properties:  props,
+			Tables:      tables,
+			AutoFilter:  autoFilter,
+			HasDrawings: hasDrawings,
+			HasCharts:   hasCharts,
+		})
+	}
+	return meta, nil
+}
+
+// WriteMetadata writes the metadata of all converted workbooks as JSON to targetPath
+func WriteMetadata(workbooks []*WorkbookMetadata, targetPath string) error {
+	file, err := os.Create(targetPath)
+	if err != nil {
+		return fmt.Errorf("error creating metadata file: %w", err)
+	}
+	defer file.Close()
+
+	if workbooks == nil {
+		workbooks = []*WorkbookMetadata{} // Written as [] rather than null
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(struct {
+		Workbooks []*WorkbookMetadata `json:"workbooks"`
+	}{workbooks}); err != nil {
+		return fmt.Errorf("error encoding metadata: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error closing metadata file: %w", err)
+	}
+	slog.Info("metadata written", "path", targetPath)
+	return nil
+}
+
+// parseInt32 parses a numeric attribute, returning 0 when it is not a valid int32
+func parseInt32(value string) int32 {
+	n, _ := strconv.ParseInt(value, 10, 32)
+	return int32(n)
+}
+
+// parseXMLBool parses an xsd:boolean attribute ("1"/"true")
+func parseXMLBool(value string) bool {
+	return value == "1" || value == "true"
+}
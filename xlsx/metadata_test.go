@@ -0,0 +1,42 @@
+package xlsx
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCustomViewAutoFilter(t *testing.T) {
+	file, err := ReadFile(filepath.Join("testdata", "customview.xlsx"), ReadOptions{AutoFilter: true}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		sheet      string
+		autoFilter string // Only the sheet's own filter, not the custom view's A1:A1
+		cells      int
+	}{
+		{"Sheet1", "A1:B3", 6},
+		{"Sheet2", "", 0},
+	}
+	for i, tt := range tests {
+		t.Run(tt.sheet, func(t *testing.T) {
+			if got := file.Metadata.Sheets[i].AutoFilter; got != tt.autoFilter {
+				t.Errorf("metadata auto filter = %q, want %q", got, tt.autoFilter)
+			}
+			cells := 0
+			for _, d := range file.Data {
+				if d.SheetName == tt.sheet {
+					cells++
+				}
+			}
+			if cells != tt.cells {
+				t.Errorf("got %d cells inside the auto filter, want %d", cells, tt.cells)
+			}
+			warned := slices.ContainsFunc(file.Warnings, func(w Warning) bool { return w.Sheet == tt.sheet })
+			if warned != (tt.autoFilter == "") {
+				t.Errorf("warned %v about the sheet, want a warning only when it has no auto filter", warned)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package xlsx
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// currencySymbols are the symbols coerceNumber removes before or after the digits
+const currencySymbols = "$€£¥₹₩₽₺¢"
+
+// spaceNormalizer turns the no-break spaces used as thousands separators into spaces
+var spaceNormalizer = strings.NewReplacer("\u00a0", " ", "\u202f", " ")
+
+// coerceNumber returns the canonical form of a number written with thousands separators,
+// a currency symbol or accounting parentheses, e.g. "$1,234.50" -> "1234.50" and
+// "(1 000)" -> "-1000", and false for anything else, including values that are already
+// plain numbers. Commas, spaces and apostrophes separate thousands, and only between
+// groups of three digits, so "1,5" or "1,2,3" stay text; the decimal point is ".".
+func coerceNumber(value string) (string, bool) {
+	s := strings.TrimSpace(spaceNormalizer.Replace(value))
+	negative, parens := false, false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, parens, s = true, true, strings.TrimSpace(s[1:len(s)-1])
+	}
+	if !parens && s != "" && (s[0] == '-' || s[0] == '+') {
+		negative, s = s[0] == '-', strings.TrimSpace(s[1:])
+	}
+
+	// One currency symbol, on either side of the digits, e.g. $12 or 12 €
+	currency := false
+	if r, size := utf8.DecodeRuneInString(s); size > 0 && strings.ContainsRune(currencySymbols, r) {
+		currency, s = true, strings.TrimSpace(s[size:])
+	} else if r, size := utf8.DecodeLastRuneInString(s); size > 0 && strings.ContainsRune(currencySymbols, r) {
+		currency, s = true, strings.TrimSpace(s[:len(s)-size])
+	}
+	if currency && !negative && strings.HasPrefix(s, "-") {
+		negative, s = true, s[1:] // $-12
+	}
+
+	integer, fraction, hasPoint := strings.Cut(s, ".")
+	if hasPoint && !allDigits(fraction) {
+		return "", false
+	}
+	digits, grouped := ungroupDigits(integer)
+	if !grouped && !currency && !parens {
+		return "", false // Already a plain number, or not a number at all
+	}
+	if digits == "" && !(hasPoint && integer == "") {
+		return "", false
+	}
+	if digits == "" {
+		digits = "0" // e.g. $.50
+	}
+	if hasPoint {
+		digits += "." + fraction
+	}
+	if negative && strings.Trim(digits, "0.") != "" {
+		digits = "-" + digits
+	}
+	return digits, true
+}
+
+// ungroupDigits returns the digits of an integer part without its thousands separators,
+// and whether it had any. It returns "" for anything but digits, optionally grouped by
+// threes with a single kind of separator.
+func ungroupDigits(s string) (string, bool) {
+	if allDigits(s) {
+		return s, false
+	}
+	sep := strings.IndexAny(s, ", '")
+	if sep <= 0 || sep > 3 {
+		return "", false
+	}
+	groups := strings.Split(s, s[sep:sep+1])
+	for i, group := range groups {
+		if !allDigits(group) || (i > 0 && len(group) != 3) {
+			return "", false
+		}
+	}
+	return strings.Join(groups, ""), true
+}
+
+// allDigits reports whether s is a non-empty run of ASCII digits
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+// Package xlsx reads the cells, merged ranges and metadata of XLSX workbooks, and writes
+// cells as CSV, JSON, Parquet, XLSX or Avro. The xml_readers command is built on it.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Document is an open XLSX workbook with its workbook part, shared strings and styles
+// already read, so sheets can be read by name without passing the zip reader around
+type Document struct {
+	Workbook      *Workbook
+	SharedStrings *SharedStrings
+	Styles        *Styles
+	Options       ReadOptions // Used by ReadSheet; Date1904 and StaleResults are taken from the workbook
+
+	Recovery *Recovery // Set by OpenRecover when the archive had to be rebuilt
+
+	zipReader archive   // With the part name index built when the Document was opened
+	closer    io.Closer // The file opened by Open, nil for OpenReader
+}
+
+// Open opens the XLSX file at path. The Document must be closed when done.
+func Open(path string) (*Document, error) {
+	zipFile, err := zip.OpenReader(path)
+	if err != nil {
+		// Encrypted .xlsx and legacy .xls files are CFB containers, say so instead of "not a valid zip file"
+		if cfbErr := detectCFB(path); cfbErr != nil {
+			return nil, cfbErr
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	doc, err := newDocument(&zipFile.Reader)
+	if err != nil {
+		zipFile.Close()
+		return nil, err
+	}
+	doc.closer = zipFile
+	return doc, nil
+}
+
+// OpenReader opens an XLSX file of the given size read through r. Nothing needs to be
+// released, so closing the Document is optional.
+func OpenReader(r io.ReaderAt, size int64) (*Document, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		if cfbErr := detectCFBReader(r); cfbErr != nil {
+			return nil, cfbErr
+		}
+		return nil, fmt.Errorf("not an XLSX file (not a zip archive): %w", err)
+	}
+	return newDocument(zipReader)
+}
+
+// newDocument indexes the archive's parts and reads the workbook-level parts shared by all sheets
+func newDocument(r *zip.Reader) (*Document, error) {
+	zipReader := indexArchive(r)
+	workbook, err := readWorkbook(zipReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workbook: %w", err)
+	}
+	sharedStrings, err := readSharedStrings(zipReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared strings: %w", err)
+	}
+	styles, err := readStyles(zipReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read styles: %w", err)
+	}
+	return &Document{Workbook: workbook, SharedStrings: sharedStrings, Styles: styles, zipReader: zipReader}, nil
+}
+
+// OpenBytes reads the workbook and shared strings of an XLSX file held in memory, e.g. an
+// upload in a web handler. The returned zip.Reader serves the other Read* functions and
+// StreamSheet; it reads from b, so b must not be modified while it is in use.
+func OpenBytes(b []byte) (*Workbook, *SharedStrings, *zip.Reader, error) {
+	doc, err := OpenReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return doc.Workbook, doc.SharedStrings, doc.zipReader.Reader, nil
+}
+
+// SheetNames returns the names of the sheets in tab order, as Excel shows them
+func (d *Document) SheetNames() []string {
+	names := make([]string, len(d.Workbook.Sheets.Sheet))
+	for i, sheet := range d.Workbook.Sheets.Sheet {
+		names[i] = sheet.Name
+	}
+	return names
+}
+
+// ReadSheet reads all cells of the named sheet, applying d.Options like ReadSheetData
+func (d *Document) ReadSheet(name string) ([]CellData, error) {
+	sheet, err := findSheet(d.Workbook, name)
+	if err != nil {
+		return nil, err
+	}
+	opts := d.Options.forWorkbook(d.Workbook)
+	return readSheetData(d.zipReader, sheet.Name, sheet.Path, d.SharedStrings, d.Styles, opts)
+}
+
+// ReadCell returns the cell at ref of the named sheet, see the ReadCell function.
+// d.Options only contributes ConvertDates and Warn, as the other options apply to whole sheets.
+func (d *Document) ReadCell(sheetName, ref string) (CellData, error) {
+	col, row, err := parseCellRef(ref)
+	if err != nil {
+		return CellData{}, err
+	}
+	sheet, err := findSheet(d.Workbook, sheetName)
+	if err != nil {
+		return CellData{}, err
+	}
+	opts := ReadOptions{ConvertDates: d.Options.ConvertDates, Warn: d.Options.Warn}.forWorkbook(d.Workbook)
+	return readCell(d.zipReader, sheet, d.SharedStrings, d.Styles, opts, col, row)
+}
+
+// ReadHeaders returns the header row of the named sheet, see the ReadHeaders function.
+// d.Options only contributes ConvertDates and Warn, as with ReadCell.
+func (d *Document) ReadHeaders(sheetName string) ([]string, error) {
+	sheet, err := findSheet(d.Workbook, sheetName)
+	if err != nil {
+		return nil, err
+	}
+	opts := ReadOptions{ConvertDates: d.Options.ConvertDates, Warn: d.Options.Warn}.forWorkbook(d.Workbook)
+	return readHeaders(d.zipReader, sheet, d.SharedStrings, d.Styles, opts)
+}
+
+// MergedCells returns the merged ranges of the named sheet
+func (d *Document) MergedCells(name string) ([]MergedCell, error) {
+	sheet, err := findSheet(d.Workbook, name)
+	if err != nil {
+		return nil, err
+	}
+	// <mergeCells> follows <sheetData>, so the whole sheet is decoded, but no cell is kept
+	extras, err := streamSheet(d.zipReader, sheet.Name, sheet.Path, d.SharedStrings, d.Styles, ReadOptions{}, func(CellData) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return extras.merges, nil
+}
+
+// Close closes the file opened by Open
+func (d *Document) Close() error {
+	if d.closer == nil {
+		return nil
+	}
+	return d.closer.Close()
+}
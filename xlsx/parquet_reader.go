@@ -0,0 +1,40 @@
+package xlsx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// readParquet reads a file written by ParquetSink back into CellData, e.g. to merge or
+// filter earlier exports without the source workbooks. Type is not stored in Parquet and
+// reads back as CellTypeNumber; a NULL value reads back as a position -dense filled.
+func readParquet(path string) ([]CellData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	reader := parquet.NewGenericReader[parquetCell](file)
+	defer reader.Close()
+
+	data := make([]CellData, 0, reader.NumRows())
+	buf := make([]parquetCell, 4096)
+	for {
+		n, err := reader.Read(buf)
+		for _, row := range buf[:n] {
+			data = append(data, row.cellData())
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading Parquet file: %w", err)
+		}
+	}
+	return data, nil
+}
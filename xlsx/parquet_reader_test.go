@@ -0,0 +1,75 @@
+package xlsx
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParquetRoundTrip(t *testing.T) {
+	tests := []struct {
+		file string
+		opts ReadOptions
+	}{
+		{"merged.xlsx", ReadOptions{}},
+		{"merged.xlsx", ReadOptions{ExpandMerged: true, Dense: true}},
+		{"inline.xlsx", ReadOptions{}},
+		{"emptysi.xlsx", ReadOptions{}},
+		{"dates1904.xlsx", ReadOptions{ConvertDates: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			want := readTestFile(t, tt.file, tt.opts).Data
+			for i := range want {
+				want[i].Type = CellTypeNumber // Not stored in Parquet
+				want[i].SourceFile = tt.file
+			}
+			path := filepath.Join(t.TempDir(), "out.parquet")
+			if err := WriteFile(want, path, "parquet", WriteOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadFile(path, ReadOptions{}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got.Data, want) {
+				t.Errorf("read back %+v, want %+v", got.Data, want)
+			}
+		})
+	}
+}
+
+func TestParquetNullsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		cell CellData
+	}{
+		{"value", CellData{SheetName: "Sheet1", RowNumber: 1, ColumnNumber: 1, SheetValue: "x"}},
+		{"empty cell", CellData{SheetName: "Sheet1", RowNumber: 1, ColumnNumber: 2}},
+		{"filled position", CellData{SheetName: "Sheet1", RowNumber: 2, ColumnNumber: 1, Filled: true}},
+		{"merged", CellData{SheetName: "Sheet1", RowNumber: 2, ColumnNumber: 2, Merged: true, MergedRange: "B2:C2"}},
+		{"source file", CellData{SheetName: "Sheet1", RowNumber: 3, ColumnNumber: 1, SheetValue: "y", SourceFile: "a.xlsx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.parquet")
+			sink, err := NewParquetSink(path, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sink.Write([]CellData{tt.cell}); err != nil {
+				t.Fatal(err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readParquet(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || got[0] != tt.cell {
+				t.Errorf("read back %+v, want %+v", got, tt.cell)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"fmt"
+	"strings"
+)
+
+// AutoFilterRange is the -range value selecting each sheet's autofilter range
+const AutoFilterRange = "auto"
+
+// SheetRange is a range reference, optionally qualified with a sheet name
+type SheetRange struct {
+	Sheet string // Empty when the range applies to every sheet
+	CellRange
+}
+
+// ReadDefinedNames reads the defined names (named ranges) of xl/workbook.xml and returns
+// name -> range, e.g. "SalesData" -> "Sheet1!$A$1:$D$100"
+func ReadDefinedNames(zipReader *zip.Reader) (map[string]string, error) {
+	return readDefinedNames(archive{Reader: zipReader})
+}
+
+func readDefinedNames(zipReader archive) (map[string]string, error) {
+	var workbook Workbook
+	if err := readXMLFromZip(zipReader, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	return workbook.definedNames(), nil
+}
+
+// definedNames maps each defined name to its range. A workbook-wide name wins over
+// sheet-scoped names with the same spelling.
+func (w *Workbook) definedNames() map[string]string {
+	names := make(map[string]string, len(w.DefinedNames.DefinedName))
+	for _, dn := range w.DefinedNames.DefinedName {
+		if _, exists := names[dn.Name]; exists && dn.LocalSheetID != "" {
+			continue
+		}
+		names[dn.Name] = strings.TrimSpace(dn.Ref)
+	}
+	return names
+}
+
+// resolveRange parses the -range value, which is either a defined name of the workbook
+// or a reference such as "A1:D100", "Sheet1!A1:D100" or "'My Sheet'!$A$1:$D$100"
+func resolveRange(spec string, names map[string]string) (SheetRange, error) {
+	if ref, ok := names[spec]; ok {
+		r, err := parseSheetRange(ref)
+		if err != nil {
+			return SheetRange{}, fmt.Errorf("defined name %s: %w", spec, err)
+		}
+		return r, nil
+	}
+	return parseSheetRange(spec)
+}
+
+// parseSheetRange parses a single-area reference. Absolute markers ($) are ignored and
+// whole columns ("A:C") or rows ("1:5") extend to the sheet limits.
+func parseSheetRange(ref string) (SheetRange, error) {
+	if strings.Contains(ref, ",") {
+		return SheetRange{}, fmt.Errorf("range %q: only a single area is supported", ref)
+	}
+	var r SheetRange
+	cells := ref
+	if i := strings.LastIndex(ref, "!"); i >= 0 {
+		r.Sheet, cells = ref[:i], ref[i+1:]
+		if len(r.Sheet) >= 2 && strings.HasPrefix(r.Sheet, "'") && strings.HasSuffix(r.Sheet, "'") {
+			r.Sheet = strings.ReplaceAll(r.Sheet[1:len(r.Sheet)-1], "''", "'")
+		}
+	}
+	cells = strings.ToUpper(strings.ReplaceAll(cells, "$", ""))
+	if cells == "" || strings.Trim(cells, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789:") != "" {
+		return SheetRange{}, fmt.Errorf("invalid range %q", ref)
+	}
+	cellRange, err := parseRangeReference(cells)
+	if err != nil {
+		return SheetRange{}, fmt.Errorf("invalid range %q: %w", ref, err)
+	}
+	// A missing column or row part selects the whole row or column
+	if cellRange.StartCol == 0 {
+		cellRange.StartCol = 1
+	}
+	if cellRange.EndCol == 0 {
+		cellRange.EndCol = MaxColumns
+	}
+	if cellRange.StartRow == 0 {
+		cellRange.StartRow = 1
+	}
+	if cellRange.EndRow == 0 {
+		cellRange.EndRow = MaxRows
+	}
+	if cellRange.StartCol > cellRange.EndCol || cellRange.StartRow > cellRange.EndRow {
+		return SheetRange{}, fmt.Errorf("invalid range %q: start is after end", ref)
+	}
+	r.CellRange = cellRange
+	return r, nil
+}
+
+// filterRange keeps only the cells inside r, reusing the backing array of data
+func filterRange(data []CellData, r SheetRange) []CellData {
+	kept := data[:0]
+	for _, d := range data {
+		if (r.Sheet == "" || d.SheetName == r.Sheet) && r.contains(d.ColumnNumber, d.RowNumber) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,188 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// localHeaderSignature starts every local file header, the copy of an entry's name and
+// sizes stored in front of its data
+var localHeaderSignature = []byte("PK\x03\x04")
+
+// dataDescriptorSignature optionally starts the CRC and sizes written after the data of
+// an entry whose local header leaves them out (general purpose flag bit 3)
+var dataDescriptorSignature = []byte("PK\x07\x08")
+
+// Recovery describes an archive rebuilt by OpenRecover
+type Recovery struct {
+	Parts []string // Parts read back intact
+	Lost  []string // Parts found but cut off or corrupt
+}
+
+// OpenRecover is Open with a fallback for damaged archives, e.g. partially downloaded
+// files whose central directory is missing: the parts are then recovered by scanning the
+// local file headers, and Document.Recovery lists what was kept and lost. Sheets whose
+// part is lost fail to read like any missing sheet.
+func OpenRecover(path string) (*Document, error) {
+	doc, err := Open(path)
+	if err == nil || errors.Is(err, ErrEncryptedWorkbook) || errors.Is(err, ErrLegacyWorkbook) || errors.Is(err, ErrBinaryWorkbook) || errors.Is(err, ErrNoSheets) {
+		return doc, err
+	}
+	b, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, err
+	}
+	zipReader, recovery, recoverErr := recoverZip(b)
+	if recoverErr != nil {
+		return nil, fmt.Errorf("%w; recovery failed: %v", err, recoverErr)
+	}
+	doc, err = newDocument(zipReader)
+	if err != nil {
+		return nil, fmt.Errorf("recovered archive: %w", err)
+	}
+	doc.Recovery = recovery
+	return doc, nil
+}
+
+// recoverZip rebuilds an archive from the local file headers of b. Entries are copied
+// without recompressing, after checking that their data decompresses completely and
+// matches its CRC; those that do not are left out and listed as lost.
+func recoverZip(b []byte) (*zip.Reader, *Recovery, error) {
+	var out bytes.Buffer
+	zipWriter := zip.NewWriter(&out)
+	recovery := &Recovery{}
+
+	for pos := 0; ; {
+		next := bytes.Index(b[pos:], localHeaderSignature)
+		if next < 0 {
+			break
+		}
+		pos += next
+		header, data, end, ok := readLocalEntry(b, pos)
+		if !ok {
+			// Cut off, corrupt, or not a real header: look for the next signature
+			if header != nil && utf8.ValidString(header.Name) && !strings.ContainsRune(header.Name, 0) {
+				recovery.Lost = append(recovery.Lost, header.Name)
+			}
+			pos += len(localHeaderSignature)
+			continue
+		}
+		pos = end
+		if strings.HasSuffix(header.Name, "/") {
+			continue // Directory entry
+		}
+		w, err := zipWriter.CreateRaw(header)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, nil, err
+		}
+		recovery.Parts = append(recovery.Parts, header.Name)
+	}
+
+	if len(recovery.Parts) == 0 {
+		return nil, nil, errors.New("no intact parts found")
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, nil, err
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		return nil, nil, err
+	}
+	return zipReader, recovery, nil
+}
+
+// readLocalEntry parses the local file header at pos and checks the entry's data. It
+// returns the header to copy the entry with, its compressed data and the offset after
+// the entry. ok is false when the data is incomplete or corrupt; the header is then
+// still returned if its name could be read.
+func readLocalEntry(b []byte, pos int) (header *zip.FileHeader, data []byte, end int, ok bool) {
+	const headerLen = 30
+	if len(b)-pos < headerLen {
+		return nil, nil, 0, false
+	}
+	h := b[pos : pos+headerLen]
+	flags := binary.LittleEndian.Uint16(h[6:8])
+	method := binary.LittleEndian.Uint16(h[8:10])
+	crc := binary.LittleEndian.Uint32(h[14:18])
+	compressedSize := binary.LittleEndian.Uint32(h[18:22])
+	nameLen := int(binary.LittleEndian.Uint16(h[26:28]))
+	extraLen := int(binary.LittleEndian.Uint16(h[28:30]))
+	dataStart := pos + headerLen + nameLen + extraLen
+	if dataStart > len(b) {
+		return nil, nil, 0, false
+	}
+	header = &zip.FileHeader{
+		Name:     string(b[pos+headerLen : pos+headerLen+nameLen]),
+		Method:   method,
+		Flags:    flags &^ 0x8, // The sizes go into the rebuilt central directory, no descriptor follows
+		Modified: zipTime(binary.LittleEndian.Uint16(h[12:14]), binary.LittleEndian.Uint16(h[10:12])),
+	}
+	hasDescriptor := flags&0x8 != 0
+
+	// Decompress the data to find where it ends and to check it is intact
+	var content io.Reader
+	var compressed *bytes.Reader
+	switch method {
+	case zip.Store:
+		if hasDescriptor || compressedSize == 0xFFFFFFFF || dataStart+int(compressedSize) > len(b) {
+			return header, nil, 0, false // Size unknown or data cut off
+		}
+		compressed = bytes.NewReader(b[dataStart : dataStart+int(compressedSize)])
+		content = compressed
+	case zip.Deflate:
+		// bytes.Reader is an io.ByteReader, so flate reads no further than the stream's end
+		compressed = bytes.NewReader(b[dataStart:])
+		content = flate.NewReader(compressed)
+	default:
+		return header, nil, 0, false
+	}
+	checksum := crc32.NewIEEE()
+	size, err := io.Copy(checksum, content)
+	if err != nil {
+		return header, nil, 0, false
+	}
+	dataEnd := dataStart + int(compressed.Size()) - compressed.Len()
+	if !hasDescriptor && checksum.Sum32() != crc {
+		return header, nil, 0, false
+	}
+	end = dataEnd
+	if hasDescriptor {
+		// The descriptor's CRC is checked when it is there; the stream already ended cleanly
+		d := b[dataEnd:]
+		if bytes.HasPrefix(d, dataDescriptorSignature) {
+			d = d[len(dataDescriptorSignature):]
+			end += len(dataDescriptorSignature)
+		}
+		if len(d) >= 12 {
+			if binary.LittleEndian.Uint32(d[:4]) != checksum.Sum32() {
+				return header, nil, 0, false
+			}
+			end += 12
+		}
+	}
+
+	header.CRC32 = checksum.Sum32()
+	header.CompressedSize64 = uint64(dataEnd - dataStart)
+	header.UncompressedSize64 = uint64(size)
+	return header, b[dataStart:dataEnd], end, true
+}
+
+// zipTime converts an MS-DOS date and time to a time.Time, as archive/zip does
+func zipTime(date, clock uint16) time.Time {
+	return time.Date(
+		int(date>>9)+1980, time.Month(date>>5&0xf), int(date&0x1f),
+		int(clock>>11), int(clock>>5&0x3f), int(clock&0x1f)*2, 0, time.UTC)
+}
@@ -0,0 +1,108 @@
+package xlsx
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// damagedCopy writes a copy of a testdata workbook without its central directory, as a
+// partial download would leave it, after applying damage to the bytes kept
+func damagedCopy(t *testing.T, name string, damage func(b []byte)) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	directory := bytes.Index(b, []byte("PK\x01\x02"))
+	if directory < 0 {
+		t.Fatalf("%s has no central directory", name)
+	}
+	b = b[:directory]
+	if damage != nil {
+		damage(b)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenRecoverTruncated(t *testing.T) {
+	tests := []struct {
+		file  string
+		parts int
+	}{
+		{"customview.xlsx", 5}, // Stored parts
+		{"fifty.xlsx", 53},     // Deflated parts
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			want := readTestFile(t, tt.file, ReadOptions{}).Data
+			path := damagedCopy(t, tt.file, nil)
+			if _, err := Open(path); err == nil {
+				t.Fatal("Open read an archive without its central directory")
+			}
+
+			doc, err := OpenRecover(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer doc.Close()
+			if doc.Recovery == nil || len(doc.Recovery.Parts) != tt.parts || len(doc.Recovery.Lost) > 0 {
+				t.Fatalf("recovery = %+v, want all %d parts intact", doc.Recovery, tt.parts)
+			}
+			var got []CellData
+			for _, name := range doc.SheetNames() {
+				cells, err := doc.ReadSheet(name)
+				if err != nil {
+					t.Fatalf("sheet %s: %v", name, err)
+				}
+				got = append(got, cells...)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("recovered cells %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestOpenRecoverCorruptEntry(t *testing.T) {
+	const lostPart = "xl/worksheets/sheet2.xml"
+	path := damagedCopy(t, "customview.xlsx", func(b []byte) {
+		// The name follows the 30 bytes of fixed fields of the part's local header,
+		// which hold the CRC at offset 14
+		for header := 0; header+30 < len(b); header++ {
+			if bytes.HasPrefix(b[header:], localHeaderSignature) && bytes.HasPrefix(b[header+30:], []byte(lostPart)) {
+				b[header+14] ^= 0xFF
+				return
+			}
+		}
+		t.Fatalf("no local header for %s", lostPart)
+	})
+
+	file, err := ReadFile(path, ReadOptions{Recover: true}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(file.Warnings, func(w Warning) bool { return strings.Contains(w.Message, "lost "+lostPart) }) {
+		t.Errorf("warnings = %v, want one listing %s as lost", file.Warnings, lostPart)
+	}
+	var missing *MissingSheetError
+	if len(file.SheetErrors) != 1 || !errors.As(file.SheetErrors[0], &missing) || missing.Path != lostPart {
+		t.Errorf("sheet errors = %v, want %s missing", file.SheetErrors, lostPart)
+	}
+	for _, d := range file.Data {
+		if d.SheetName != "Sheet1" {
+			t.Fatalf("cell %+v read from the lost sheet", d)
+		}
+	}
+	if len(file.Data) == 0 {
+		t.Error("the intact sheet was not read")
+	}
+}
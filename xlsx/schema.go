@@ -0,0 +1,223 @@
+package xlsx
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// Schema describes the kind of values found in each column of each sheet written, the
+// types a typed consumer of the cell-per-row output would give the columns
+type Schema struct {
+	Sheets []SheetSchema `json:"sheets"`
+}
+
+// SheetSchema holds the columns of one sheet that have at least one value, in column order
+type SheetSchema struct {
+	Name    string         `json:"name"`
+	Columns []ColumnSchema `json:"columns"`
+}
+
+// ColumnSchema is the type inferred for a column from the types of its cells: number,
+// string, boolean, date or error. A column holding several of them is a string column,
+// the type every value can be written as, and lists them under Observed. Error values
+// such as #N/A do not change the type of a column that holds other values.
+type ColumnSchema struct {
+	Column   string   `json:"column"` // Column letter, e.g. B
+	Type     string   `json:"type"`
+	Observed []string `json:"observed,omitempty"` // The types seen, when there is more than one
+	Cells    int      `json:"cells"`              // Cells with a value
+}
+
+// Names of the column types, in the order they are listed under ColumnSchema.Observed
+var schemaTypes = []string{"number", "string", "boolean", "date", "error"}
+
+// schemaTypeOf returns the schema type of a cell's value. Cells read from Parquet carry
+// no type and read back as numbers, so a number that does not parse is a string.
+func schemaTypeOf(d CellData) string {
+	switch {
+	case d.Type.IsString():
+		return "string"
+	case d.Type == CellTypeBool:
+		return "boolean"
+	case d.Type == CellTypeDate:
+		return "date"
+	case d.Type == CellTypeError:
+		return "error"
+	case !isNumericValue(d.SheetValue):
+		return "string"
+	}
+	return "number"
+}
+
+// columnTypes counts the cells of each type found in a column
+type columnTypes map[string]int
+
+// SchemaBuilder collects the types of the cells passed to Add, sheet by sheet, so the
+// schema of a run can be built while its cells are streamed. Sheets of the same name from
+// several files share their columns, as they do in a merged output. Given the schema of
+// an earlier run, it also checks cells against the types pinned there. A nil
+// SchemaBuilder ignores the cells, for runs without -schema.
+type SchemaBuilder struct {
+	sheets []string                         // Sheet names, in the order they were first seen
+	types  map[string]map[int32]columnTypes // Sheet -> column -> types
+	pinned map[string]map[int32]string      // Sheet -> column -> type, nil when not pinned
+}
+
+// NewSchemaBuilder returns an empty SchemaBuilder. With pinned set, Check reports the
+// cells whose values do not fit the column types it gives.
+func NewSchemaBuilder(pinned *Schema) *SchemaBuilder {
+	b := &SchemaBuilder{types: make(map[string]map[int32]columnTypes)}
+	if pinned != nil {
+		b.pinned = make(map[string]map[int32]string, len(pinned.Sheets))
+		for _, sheet := range pinned.Sheets {
+			columns := make(map[int32]string, len(sheet.Columns))
+			for _, column := range sheet.Columns {
+				col, _ := ColumnToIndex(column.Column) // Checked by ReadSchema
+				columns[col] = column.Type
+			}
+			b.pinned[sheet.Name] = columns
+		}
+	}
+	return b
+}
+
+// Add records the types of the cells' values. Empty cells, and the positions -dense
+// filled, have no type and are skipped.
+func (b *SchemaBuilder) Add(cells []CellData) {
+	if b == nil {
+		return
+	}
+	for _, d := range cells {
+		if d.Filled || d.SheetValue == "" {
+			continue
+		}
+		columns, ok := b.types[d.SheetName]
+		if !ok {
+			columns = make(map[int32]columnTypes)
+			b.types[d.SheetName] = columns
+			b.sheets = append(b.sheets, d.SheetName)
+		}
+		if columns[d.ColumnNumber] == nil {
+			columns[d.ColumnNumber] = make(columnTypes)
+		}
+		columns[d.ColumnNumber][schemaTypeOf(d)]++
+	}
+}
+
+// Check returns a warning for each cell whose value does not fit the type pinned for its
+// column: a string column takes any value, other columns only their own type, and error
+// values fit every column. Cells of columns or sheets the pinned schema does not list are
+// reported too. Without a pinned schema nothing is reported.
+func (b *SchemaBuilder) Check(cells []CellData) []Warning {
+	if b == nil || b.pinned == nil {
+		return nil
+	}
+	var warnings []Warning
+	for _, d := range cells {
+		if d.Filled || d.SheetValue == "" {
+			continue
+		}
+		ref := FormatRef(d.ColumnNumber, d.RowNumber)
+		pinned, ok := b.pinned[d.SheetName][d.ColumnNumber]
+		if !ok {
+			warnings = append(warnings, Warning{Sheet: d.SheetName, Ref: ref, Message: fmt.Sprintf("column %s is not in the schema", IndexToColumn(d.ColumnNumber))})
+			continue
+		}
+		if got := schemaTypeOf(d); got != pinned && got != "error" && pinned != "string" {
+			warnings = append(warnings, Warning{Sheet: d.SheetName, Ref: ref, Message: fmt.Sprintf("%s value in %s column %s", got, pinned, IndexToColumn(d.ColumnNumber))})
+		}
+	}
+	return warnings
+}
+
+// Schema returns the types inferred from the cells added so far
+func (b *SchemaBuilder) Schema() *Schema {
+	schema := &Schema{Sheets: []SheetSchema{}}
+	for _, sheet := range b.sheets {
+		columns := b.types[sheet]
+		numbers := make([]int32, 0, len(columns))
+		for col := range columns {
+			numbers = append(numbers, col)
+		}
+		slices.Sort(numbers)
+		sheetSchema := SheetSchema{Name: sheet, Columns: make([]ColumnSchema, 0, len(numbers))}
+		for _, col := range numbers {
+			sheetSchema.Columns = append(sheetSchema.Columns, columns[col].infer(col))
+		}
+		schema.Sheets = append(schema.Sheets, sheetSchema)
+	}
+	return schema
+}
+
+// infer picks the type of column col from the types counted in it
+func (t columnTypes) infer(col int32) ColumnSchema {
+	column := ColumnSchema{Column: IndexToColumn(col), Type: "error"}
+	var values []string // The types seen besides errors
+	for _, name := range schemaTypes {
+		if t[name] == 0 {
+			continue
+		}
+		column.Cells += t[name]
+		column.Observed = append(column.Observed, name)
+		if name != "error" {
+			values = append(values, name)
+		}
+	}
+	switch len(values) {
+	case 0: // Only errors
+	case 1:
+		column.Type = values[0]
+	default:
+		column.Type = "string"
+	}
+	if len(column.Observed) == 1 {
+		column.Observed = nil
+	}
+	return column
+}
+
+// WriteSchema writes the schema as JSON to targetPath
+func WriteSchema(schema *Schema, targetPath string) error {
+	file, err := os.Create(targetPath)
+	if err != nil {
+		return fmt.Errorf("error creating schema file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return fmt.Errorf("error encoding schema: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error closing schema file: %w", err)
+	}
+	slog.Info("schema written", "path", targetPath)
+	return nil
+}
+
+// ReadSchema reads a schema written by WriteSchema, to pin the column types of a later run
+func ReadSchema(sourcePath string) (*Schema, error) {
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema file: %w", err)
+	}
+	var schema Schema
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("error decoding schema file %s: %w", sourcePath, err)
+	}
+	for _, sheet := range schema.Sheets {
+		for _, column := range sheet.Columns {
+			if _, err := ColumnToIndex(column.Column); err != nil {
+				return nil, fmt.Errorf("schema file %s, sheet %s: %w", sourcePath, sheet.Name, err)
+			}
+			if !slices.Contains(schemaTypes, column.Type) {
+				return nil, fmt.Errorf("schema file %s, sheet %s: unknown type %q for column %s", sourcePath, sheet.Name, column.Type, column.Column)
+			}
+		}
+	}
+	return &schema, nil
+}
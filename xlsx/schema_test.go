@@ -0,0 +1,129 @@
+package xlsx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestColumnTypesInfer(t *testing.T) {
+	tests := []struct {
+		name  string
+		types columnTypes
+		want  ColumnSchema
+	}{
+		{"numbers", columnTypes{"number": 3}, ColumnSchema{Column: "B", Type: "number", Cells: 3}},
+		{"dates", columnTypes{"date": 2}, ColumnSchema{Column: "B", Type: "date", Cells: 2}},
+		{"numbers and errors", columnTypes{"number": 2, "error": 1}, ColumnSchema{Column: "B", Type: "number", Observed: []string{"number", "error"}, Cells: 3}},
+		{"only errors", columnTypes{"error": 2}, ColumnSchema{Column: "B", Type: "error", Cells: 2}},
+		{"mixed", columnTypes{"boolean": 1, "number": 1, "error": 1}, ColumnSchema{Column: "B", Type: "string", Observed: []string{"number", "boolean", "error"}, Cells: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.types.infer(2); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("infer = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaBuilder(t *testing.T) {
+	b := NewSchemaBuilder(nil)
+	b.Add([]CellData{
+		{SheetName: "Data", RowNumber: 1, ColumnNumber: 2, SheetValue: "1.5"},
+		{SheetName: "Data", RowNumber: 1, ColumnNumber: 1, SheetValue: "x", Type: CellTypeSharedString},
+		{SheetName: "Data", RowNumber: 2, ColumnNumber: 1, Filled: true},      // -dense position, skipped
+		{SheetName: "Data", RowNumber: 2, ColumnNumber: 3, SheetValue: ""},    // Empty, skipped
+		{SheetName: "Data", RowNumber: 2, ColumnNumber: 2, SheetValue: "abc"}, // Untyped, from Parquet
+		{SheetName: "Notes", RowNumber: 1, ColumnNumber: 1, SheetValue: "TRUE", Type: CellTypeBool},
+	})
+	// A second file with the same sheet shares its columns
+	b.Add([]CellData{{SheetName: "Data", RowNumber: 1, ColumnNumber: 1, SheetValue: "y", Type: CellTypeInlineString}})
+	want := &Schema{Sheets: []SheetSchema{
+		{Name: "Data", Columns: []ColumnSchema{
+			{Column: "A", Type: "string", Cells: 2},
+			{Column: "B", Type: "string", Observed: []string{"number", "string"}, Cells: 2},
+		}},
+		{Name: "Notes", Columns: []ColumnSchema{{Column: "A", Type: "boolean", Cells: 1}}},
+	}}
+	if got := b.Schema(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Schema() = %+v, want %+v", got, want)
+	}
+
+	var none *SchemaBuilder
+	none.Add([]CellData{{SheetName: "Data", SheetValue: "x"}}) // Ignored without -schema
+	if warnings := none.Check([]CellData{{SheetName: "Data", SheetValue: "x"}}); warnings != nil {
+		t.Errorf("nil builder reported %v", warnings)
+	}
+}
+
+func TestPinnedSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	written := &Schema{Sheets: []SheetSchema{{Name: "Data", Columns: []ColumnSchema{
+		{Column: "A", Type: "number", Cells: 2},
+		{Column: "B", Type: "string", Observed: []string{"number", "string"}, Cells: 2},
+		{Column: "C", Type: "date", Cells: 1},
+	}}}}
+	if err := WriteSchema(written, path); err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := ReadSchema(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pinned, written) {
+		t.Fatalf("ReadSchema = %+v, want %+v", pinned, written)
+	}
+
+	b := NewSchemaBuilder(pinned)
+	tests := []struct {
+		name string
+		cell CellData
+		want string // Warning message, empty for none
+	}{
+		{"number in number column", CellData{SheetName: "Data", RowNumber: 1, ColumnNumber: 1, SheetValue: "3"}, ""},
+		{"error in number column", CellData{SheetName: "Data", RowNumber: 2, ColumnNumber: 1, SheetValue: "#N/A", Type: CellTypeError}, ""},
+		{"string in number column", CellData{SheetName: "Data", RowNumber: 3, ColumnNumber: 1, SheetValue: "x", Type: CellTypeSharedString}, "string value in number column A"},
+		{"boolean in string column", CellData{SheetName: "Data", RowNumber: 1, ColumnNumber: 2, SheetValue: "1", Type: CellTypeBool}, ""},
+		{"number in date column", CellData{SheetName: "Data", RowNumber: 1, ColumnNumber: 3, SheetValue: "45000"}, "number value in date column C"},
+		{"empty cell", CellData{SheetName: "Data", RowNumber: 1, ColumnNumber: 4}, ""},
+		{"column not in schema", CellData{SheetName: "Data", RowNumber: 1, ColumnNumber: 4, SheetValue: "1"}, "column D is not in the schema"},
+		{"sheet not in schema", CellData{SheetName: "Other", RowNumber: 1, ColumnNumber: 1, SheetValue: "1"}, "column A is not in the schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := b.Check([]CellData{tt.cell})
+			switch {
+			case tt.want == "" && len(warnings) > 0:
+				t.Errorf("unexpected warnings: %v", warnings)
+			case tt.want != "" && (len(warnings) != 1 || warnings[0].Message != tt.want):
+				t.Errorf("warnings = %v, want %q", warnings, tt.want)
+			case tt.want != "" && warnings[0].Ref != FormatRef(tt.cell.ColumnNumber, tt.cell.RowNumber):
+				t.Errorf("warning about %s, want %s", warnings[0].Ref, FormatRef(tt.cell.ColumnNumber, tt.cell.RowNumber))
+			}
+		})
+	}
+}
+
+func TestReadSchemaInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not JSON", "{"},
+		{"bad column", `{"sheets": [{"name": "Data", "columns": [{"column": "A1", "type": "number"}]}]}`},
+		{"unknown type", `{"sheets": [{"name": "Data", "columns": [{"column": "A", "type": "integer"}]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schema.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadSchema(path); err == nil {
+				t.Errorf("ReadSchema accepted %s", tt.content)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package xlsx
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Variables rather than constants so that tests can split small sheets
+var (
+	// splitThreshold is the uncompressed size from which a sheet is decoded in parallel
+	splitThreshold uint64 = 32 << 20
+	// splitChunkSize is the approximate size of the chunks handed to the decode workers
+	splitChunkSize = 4 << 20
+)
+
+// readSheetCells reads the cells and extras of a worksheet part for ReadSheetData. Large
+// parts are decoded on several goroutines by readSheetSplit, smaller ones by streamSheet.
+func readSheetCells(zipReader archive, sheetName, fileName string, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions) ([]CellData, *sheetExtras, error) {
+	workers := opts.SheetWorkers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	file := zipReader.find(fileName)
+	// Sampling counts the rows from the top of the sheet, so it needs a single decoder
+	if file == nil || workers < 2 || file.UncompressedSize64 < splitThreshold || opts.Sample > 1 {
+		var cellData []CellData
+		extras, err := streamSheet(zipReader, sheetName, fileName, sharedStrings, styles, opts, func(d CellData) error {
+			cellData = append(cellData, d)
+			return nil
+		})
+		return cellData, extras, err
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	start := time.Now()
+	counter := &countingReader{r: f}
+	cellData, extras, err := readSheetSplit(counter, sheetName, sharedStrings, styles, opts, workers)
+	if err != nil {
+		return nil, nil, err
+	}
+	extras.bytes = counter.n
+	finishSheet(sheetName, extras, opts, start)
+	return cellData, extras, nil
+}
+
+// sheetChunk is a run of whole rows of a worksheet part, decoded by one worker
+type sheetChunk struct {
+	data   []byte
+	cells  []CellData
+	extras *sheetExtras
+	err    error
+}
+
+// readSheetSplit decodes a worksheet part on the given number of goroutines. The part is
+// one compressed stream, so it is still inflated sequentially, but the XML decoding,
+// which takes most of the time, runs in parallel: the inflated bytes are cut into chunks
+// just before a <row> start tag and each chunk is decoded on its own, see splitRows. The
+// chunks' cells are joined in document order, so the result is the same as streamSheet's,
+// apart from the order in which warnings are reported.
+func readSheetSplit(r io.Reader, sheetName string, sharedStrings *SharedStrings, styles *Styles, opts ReadOptions, workers int) ([]CellData, *sheetExtras, error) {
+	// Progress is reported here for the whole sheet rather than per chunk
+	progress := opts.Progress
+	opts.Progress = nil
+	var progressMu sync.Mutex
+	rowsRead := 0
+
+	var chunks []*sheetChunk
+	jobs := make(chan *sheetChunk, workers) // Bounds the inflated data held ahead of the workers
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				chunk.extras, chunk.err = decodeSheet(bytes.NewReader(chunk.data), sheetName, sharedStrings, styles, opts, func(d CellData) error {
+					chunk.cells = append(chunk.cells, d)
+					return nil
+				})
+				chunk.data = nil
+				if progress != nil && chunk.err == nil {
+					progressMu.Lock()
+					before := rowsRead / opts.progressEvery()
+					rowsRead += chunk.extras.rows
+					if rowsRead/opts.progressEvery() > before {
+						progress(sheetName, rowsRead)
+					}
+					progressMu.Unlock()
+				}
+			}
+		}()
+	}
+	splitErr := splitRows(partReader(r, max(ReadBufferSize, MinReadBufferSize)), splitChunkSize, func(data []byte) {
+		chunk := &sheetChunk{data: data}
+		chunks = append(chunks, chunk)
+		jobs <- chunk
+	})
+	close(jobs)
+	wg.Wait()
+	if splitErr != nil {
+		return nil, nil, splitErr
+	}
+
+	// Join the chunks in document order. Each chunk numbered its rows from its own first
+	// row, so the order of the rows on either side of a cut is checked here.
+	total := 0
+	for _, chunk := range chunks {
+		if chunk.err != nil {
+			return nil, nil, chunk.err
+		}
+		total += len(chunk.cells)
+	}
+	cellData := make([]CellData, 0, total)
+	extras := &sheetExtras{}
+	for i, chunk := range chunks {
+		if i > 0 && extras.lastRow > 0 && chunk.extras.firstRow > 0 && chunk.extras.firstRow <= extras.lastRow {
+			opts.warn(sheetName, "", "row %d is out of order, it comes after row %d", chunk.extras.firstRow, extras.lastRow)
+		}
+		cellData = append(cellData, chunk.cells...)
+		extras.merge(chunk.extras)
+	}
+	return cellData, extras, nil
+}
+
+// merge adds the extras of the next chunk of the same sheet
+func (e *sheetExtras) merge(next *sheetExtras) {
+	if e.dimension == nil {
+		e.dimension = next.dimension
+	}
+	if e.autoFilter == nil {
+		e.autoFilter = next.autoFilter
+	}
+	e.merges = append(e.merges, next.merges...)
+	if next.spanEnd > 0 {
+		if e.spanEnd == 0 || next.spanStart < e.spanStart {
+			e.spanStart = next.spanStart
+		}
+		e.spanEnd = max(e.spanEnd, next.spanEnd)
+	}
+	if e.firstRow == 0 {
+		e.firstRow = next.firstRow
+	}
+	if next.lastRow > 0 {
+		e.lastRow = next.lastRow
+	}
+	e.rows += next.rows
+	e.formulas += next.formulas
+	e.tokens += next.tokens
+	e.cells += next.cells
+}
+
+// splitRows reads worksheet XML from r and hands it to chunk in pieces of about size
+// bytes, each cut just before a <row> start tag so that every piece holds whole rows.
+// The first piece also holds what precedes the rows and the last what follows them;
+// xml.Decoder.RawToken does not require the start and end tags to match, so each piece
+// can be decoded by itself.
+func splitRows(r io.Reader, size int, chunk func([]byte)) error {
+	buf := make([]byte, 0, 2*size)
+	for {
+		// Fill the buffer up to size, or up to the end of the part
+		for len(buf) < size {
+			n, err := r.Read(buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+n]
+			if err == io.EOF {
+				if len(buf) > 0 {
+					chunk(buf)
+				}
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if len(buf) == cap(buf) {
+				buf = append(buf, 0)[:len(buf)] // Grow, keeping the contents
+			}
+		}
+		cut := lastRowStart(buf)
+		if cut <= 0 {
+			// No safe place to cut yet, e.g. a single huge row: read on
+			size += len(buf)
+			continue
+		}
+		next := make([]byte, len(buf)-cut, max(2*splitChunkSize, 2*(len(buf)-cut)))
+		copy(next, buf[cut:])
+		chunk(buf[:cut])
+		buf, size = next, splitChunkSize
+	}
+}
+
+// lastRowStart returns the offset of the last <row> start tag in b that carries a valid
+// row number (an r attribute between 1 and MaxRows) and is complete, or -1. A row without
+// r, or with an invalid one, takes its number from the row before it, which a chunk
+// starting there would not know. Offsets inside a comment or CDATA section that is still
+// open are not used either, as "<row" there is text.
+func lastRowStart(b []byte) int {
+	for end := len(b); ; {
+		i := bytes.LastIndex(b[:end], []byte("row"))
+		if i < 0 {
+			return -1
+		}
+		end = i
+		// The tag name is "row" or a prefixed name such as "x:row"
+		start := i - 1
+		if start >= 0 && b[start] == ':' {
+			for start--; start >= 0 && isNameByte(b[start]); start-- {
+			}
+		}
+		if start < 0 || b[start] != '<' || i+3 >= len(b) || !isSpaceByte(b[i+3]) {
+			continue
+		}
+		tagEnd := bytes.IndexByte(b[i:], '>')
+		if tagEnd < 0 {
+			continue // The tag is cut off
+		}
+		if _, ok := rowNumberAttr(b[i+3 : i+tagEnd]); !ok {
+			continue
+		}
+		if insideMarkupSection(b[:start]) {
+			continue
+		}
+		return start
+	}
+}
+
+// rowNumberAttr returns the value of the r attribute among the attributes of a <row>
+// start tag, if it is a valid row number
+func rowNumberAttr(attrs []byte) (int32, bool) {
+	for j := 1; j < len(attrs); j++ {
+		if attrs[j] != 'r' || !isSpaceByte(attrs[j-1]) {
+			continue
+		}
+		k := j + 1
+		for k < len(attrs) && isSpaceByte(attrs[k]) {
+			k++
+		}
+		if k == len(attrs) || attrs[k] != '=' {
+			continue // Another attribute starting with r, e.g. a prefixed one
+		}
+		for k++; k < len(attrs) && isSpaceByte(attrs[k]); k++ {
+		}
+		if k == len(attrs) || attrs[k] != '"' && attrs[k] != '\'' {
+			return 0, false
+		}
+		value, _, found := bytes.Cut(attrs[k+1:], attrs[k:k+1])
+		if !found {
+			return 0, false
+		}
+		row, err := strconv.ParseInt(string(value), 10, 32)
+		if err != nil || row < 1 || row > MaxRows {
+			return 0, false
+		}
+		return int32(row), true
+	}
+	return 0, false
+}
+
+// insideMarkupSection reports whether b ends inside a comment or CDATA section
+func insideMarkupSection(b []byte) bool {
+	open := bytes.LastIndex(b, []byte("<!"))
+	if open < 0 {
+		return false
+	}
+	rest := b[open:]
+	switch {
+	case bytes.HasPrefix(rest, []byte("<!--")):
+		return !bytes.Contains(rest[4:], []byte("-->"))
+	case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+		return !bytes.Contains(rest, []byte("]]>"))
+	}
+	return !bytes.Contains(rest, []byte(">")) // e.g. a DOCTYPE
+}
+
+// isNameByte reports whether c can be part of an ASCII XML name such as a namespace prefix
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// isSpaceByte reports whether c is XML whitespace
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,154 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// splitTestSheet has rows that are not safe places to cut: rows without r or with an
+// invalid one, and "<row" inside a comment and a CDATA section. It also has a row out of
+// order, whose warning must survive a cut just before it.
+const splitTestSheet = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><dimension ref="A1:C9"/><sheetData>
+<row r="1" spans="1:2"><c r="A1" t="inlineStr"><is><t>a &lt;row r="7"&gt;</t></is></c><c r="B1"><v>1</v></c></row>
+<!-- <row r="50"><c r="A50"><v>50</v></c></row> -->
+<row r="2"><c r="A2"><v>2</v></c></row>
+<row><c r="A3"><v>3</v></c></row>
+<row><c><v>4</v></c></row>
+<row r="0"><c><v>5</v></c></row>
+<row r="abc"><c><v>6</v></c></row>
+<row r="8"><c r="A8" t="inlineStr"><is><t><![CDATA[<row r="60"><c r="A60">]]></t></is></c></row>
+<row r="7"><c r="A7"><v>7</v></c></row>
+<row  r = '9' spans="1:3"><c r="C9"><f>A7+2</f><v>9</v></c></row>
+</sheetData><mergeCells count="1"><mergeCell ref="A1:B1"/></mergeCells></worksheet>`
+
+// prefixSheet puts the elements of a worksheet in the x namespace prefix, as some
+// producers write them
+func prefixSheet(sheet string) string {
+	sheet = strings.NewReplacer("</", "</x:", "<?", "<?", "<!", "<!", "<", "<x:").Replace(sheet)
+	return strings.Replace(sheet, `xmlns=`, `xmlns:x=`, 1)
+}
+
+// zipSheet returns an archive holding sheet as xl/worksheets/sheet1.xml
+func zipSheet(t *testing.T, sheet string) archive {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	part, err := w.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte(sheet)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archive{Reader: zipReader}
+}
+
+// warningCollector gathers the warnings of a read, which split sheets report from
+// several goroutines
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (c *warningCollector) warn(w Warning) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, w.String())
+}
+
+// sorted returns the warnings in a fixed order, as a split sheet reports them in the
+// order its chunks are decoded
+func (c *warningCollector) sorted() []string {
+	return slices.Sorted(slices.Values(c.warnings))
+}
+
+func TestReadSheetSplit(t *testing.T) {
+	threshold, chunkSize := splitThreshold, splitChunkSize
+	t.Cleanup(func() { splitThreshold, splitChunkSize = threshold, chunkSize })
+	splitThreshold = 0
+
+	for _, sheet := range []struct{ name, xml string }{
+		{"plain", splitTestSheet},
+		{"prefixed", prefixSheet(splitTestSheet)},
+	} {
+		t.Run(sheet.name, func(t *testing.T) {
+			zipReader := zipSheet(t, sheet.xml)
+			var wantWarnings warningCollector
+			var want []CellData
+			wantExtras, err := streamSheet(zipReader, "Sheet1", "xl/worksheets/sheet1.xml", &SharedStrings{}, nil, ReadOptions{Warn: wantWarnings.warn}, func(d CellData) error {
+				want = append(want, d)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(wantWarnings.warnings) != 3 {
+				t.Fatalf("streamSheet warnings = %v, want two invalid row numbers and a row out of order", wantWarnings.warnings)
+			}
+
+			// Every chunk size cuts the sheet at other places, inside tags as well
+			for size := 8; size <= len(sheet.xml); size += 5 {
+				splitChunkSize = size
+				var gotWarnings warningCollector
+				got, gotExtras, err := readSheetCells(zipReader, "Sheet1", "xl/worksheets/sheet1.xml", &SharedStrings{}, nil, ReadOptions{SheetWorkers: 3, Warn: gotWarnings.warn})
+				if err != nil {
+					t.Fatalf("chunks of %d bytes: %v", size, err)
+				}
+				if !slices.Equal(got, want) {
+					t.Fatalf("chunks of %d bytes: cells %+v, want %+v", size, got, want)
+				}
+				if !slices.Equal(gotWarnings.sorted(), wantWarnings.sorted()) {
+					t.Fatalf("chunks of %d bytes: warnings %v, want %v", size, gotWarnings.sorted(), wantWarnings.sorted())
+				}
+				if gotExtras.rows != wantExtras.rows || gotExtras.cells != wantExtras.cells || gotExtras.formulas != wantExtras.formulas ||
+					!reflect.DeepEqual(gotExtras.merges, wantExtras.merges) || gotExtras.spanStart != wantExtras.spanStart || gotExtras.spanEnd != wantExtras.spanEnd {
+					t.Fatalf("chunks of %d bytes: extras %+v, want %+v", size, gotExtras, wantExtras)
+				}
+			}
+		})
+	}
+}
+
+func TestLastRowStart(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want int
+	}{
+		{"row with r", `<sheetData><row r="2">`, 11},
+		{"last of several", `<row r="1"></row><row r="2">`, 17},
+		{"prefixed", `<x:sheetData><x:row r="3">`, 13},
+		{"single quotes and spaces", `<row  r = '4'>`, 0},
+		{"no r", `<row r="1"></row><row>`, 0},
+		{"empty r", `<row r="1"></row><row r="">`, 0},
+		{"r out of range", `<row r="1"></row><row r="0"></row><row r="1048577">`, 0},
+		{"r not a number", `<row r="1"></row><row r="abc">`, 0},
+		{"other attribute ending in r", `<row r="1"></row><row xr="5">`, 0},
+		{"cut inside the tag", `<row r="1"></row><row r="2`, 0},
+		{"cut after the tag name", `<row r="1"></row><row`, 0},
+		{"in an open comment", `<row r="1"></row><!-- <row r="2">`, 0},
+		{"after a closed comment", `<!-- x --><row r="2">`, 10},
+		{"in an open CDATA section", `<row r="1"></row><t><![CDATA[<row r="2">`, 0},
+		{"not a row tag", `<rows r="1">`, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lastRowStart([]byte(tt.xml)); got != tt.want {
+				t.Errorf("lastRowStart(%q) = %d, want %d", tt.xml, got, tt.want)
+			}
+		})
+	}
+}
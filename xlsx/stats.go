@@ -0,0 +1,28 @@
+package xlsx
+
+import (
+	"io"
+	"time"
+)
+
+// ParseStats describes the work done to read one sheet, reported through ReadOptions.Stats
+type ParseStats struct {
+	Sheet    string
+	Bytes    int64         // Uncompressed size of the worksheet part
+	Tokens   int64         // XML tokens decoded
+	Rows     int           // <row> elements read
+	Cells    int           // Cells decoded, before dense filling and merged-range expansion
+	Duration time.Duration // Wall time from opening the part to the last cell
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
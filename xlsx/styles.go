@@ -0,0 +1,292 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateKind tells how a number format displays a serial value
+type dateKind int
+
+const (
+	notDate      dateKind = iota
+	dateOnly              // e.g. yyyy-mm-dd
+	timeOnly              // e.g. hh:mm:ss
+	dateWithTime          // e.g. m/d/yy h:mm
+)
+
+// Styles holds the parts of xl/styles.xml needed to recognise date and time cells, and
+// the fonts, fills and borders listed by Catalog
+type Styles struct {
+	NumFmts struct {
+		NumFmt []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmt"`
+	} `xml:"numFmts"`
+	Fonts struct {
+		Font []styleFont `xml:"font"`
+	} `xml:"fonts"`
+	Fills struct {
+		Fill []styleFill `xml:"fill"`
+	} `xml:"fills"`
+	Borders struct {
+		Border []styleBorder `xml:"border"`
+	} `xml:"borders"`
+	CellXfs struct {
+		Xf []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+			FontID   int `xml:"fontId,attr"`
+			FillID   int `xml:"fillId,attr"`
+			BorderID int `xml:"borderId,attr"`
+		} `xml:"xf"`
+	} `xml:"cellXfs"`
+
+	kinds []dateKind // dateKind per cellXfs index, filled by ReadStyles
+}
+
+// styleVal is a font property element such as <sz val="11"/> or <b/>
+type styleVal struct {
+	Val string `xml:"val,attr"`
+}
+
+// on reports whether a toggle such as <b/> is set: present, and not turned off by val="0"
+func (v *styleVal) on() bool {
+	return v != nil && (v.Val == "" || parseXMLBool(v.Val))
+}
+
+// styleFont is a <font> of the <fonts> list
+type styleFont struct {
+	Name      *styleVal `xml:"name"`
+	Size      *styleVal `xml:"sz"`
+	Bold      *styleVal `xml:"b"`
+	Italic    *styleVal `xml:"i"`
+	Underline *styleVal `xml:"u"` // val is the underline style, "single" when absent
+	Strike    *styleVal `xml:"strike"`
+	Color     *TabColor `xml:"color"`
+}
+
+// styleFill is a <fill> of the <fills> list. Gradient fills are not described.
+type styleFill struct {
+	Pattern *struct {
+		Type    string    `xml:"patternType,attr"`
+		FgColor *TabColor `xml:"fgColor"`
+	} `xml:"patternFill"`
+}
+
+// styleBorder is a <border> of the <borders> list
+type styleBorder struct {
+	Sides []struct {
+		XMLName xml.Name
+		Style   string `xml:"style,attr"`
+	} `xml:",any"`
+}
+
+// FontStyle describes a font of the style catalog
+type FontStyle struct {
+	Name      string    `json:"name,omitempty"`
+	Size      float64   `json:"size,omitempty"`
+	Bold      bool      `json:"bold,omitempty"`
+	Italic    bool      `json:"italic,omitempty"`
+	Underline bool      `json:"underline,omitempty"`
+	Strike    bool      `json:"strike,omitempty"`
+	Color     *TabColor `json:"color,omitempty"`
+}
+
+// CellStyle is an entry of the style catalog: a cell format (<xf> of cellXfs), with the
+// number format, font, fill and borders it refers to resolved
+type CellStyle struct {
+	ID          int       `json:"id"`                     // Index in cellXfs, the s attribute of the cells using it
+	NumFmtID    int       `json:"num_fmt_id"`             // Number format, built in below 164
+	NumFmt      string    `json:"num_fmt,omitempty"`      // Format code of a custom number format
+	Font        FontStyle `json:"font"`                   // The font, empty when fontId is out of range
+	FillPattern string    `json:"fill_pattern,omitempty"` // e.g. solid; empty for no fill
+	FillColor   *TabColor `json:"fill_color,omitempty"`   // Foreground colour of a pattern fill
+	Borders     []string  `json:"borders,omitempty"`      // Sides with a border, e.g. left, bottom
+}
+
+// Catalog lists the cell formats of the workbook, indexed like CellData.StyleID
+func (s *Styles) Catalog() []CellStyle {
+	custom := make(map[int]string, len(s.NumFmts.NumFmt))
+	for _, f := range s.NumFmts.NumFmt {
+		custom[f.ID] = f.Code
+	}
+	catalog := make([]CellStyle, len(s.CellXfs.Xf))
+	for i, xf := range s.CellXfs.Xf {
+		style := CellStyle{ID: i, NumFmtID: xf.NumFmtID, NumFmt: custom[xf.NumFmtID]}
+		if xf.FontID >= 0 && xf.FontID < len(s.Fonts.Font) {
+			font := s.Fonts.Font[xf.FontID]
+			if font.Name != nil {
+				style.Font.Name = font.Name.Val
+			}
+			if font.Size != nil {
+				style.Font.Size, _ = strconv.ParseFloat(font.Size.Val, 64)
+			}
+			style.Font.Bold = font.Bold.on()
+			style.Font.Italic = font.Italic.on()
+			style.Font.Underline = font.Underline != nil && font.Underline.Val != "none"
+			style.Font.Strike = font.Strike.on()
+			style.Font.Color = font.Color
+		}
+		if xf.FillID >= 0 && xf.FillID < len(s.Fills.Fill) {
+			if pattern := s.Fills.Fill[xf.FillID].Pattern; pattern != nil && pattern.Type != "" && pattern.Type != "none" {
+				style.FillPattern = pattern.Type
+				style.FillColor = pattern.FgColor
+			}
+		}
+		if xf.BorderID >= 0 && xf.BorderID < len(s.Borders.Border) {
+			for _, side := range s.Borders.Border[xf.BorderID].Sides {
+				if side.Style != "" && side.Style != "none" {
+					style.Borders = append(style.Borders, side.XMLName.Local)
+				}
+			}
+		}
+		catalog[i] = style
+	}
+	return catalog
+}
+
+// builtinDateKinds lists the built-in number formats that display dates or times
+var builtinDateKinds = map[int]dateKind{
+	14: dateOnly, 15: dateOnly, 16: dateOnly, 17: dateOnly,
+	18: timeOnly, 19: timeOnly, 20: timeOnly, 21: timeOnly,
+	22: dateWithTime,
+	45: timeOnly, 46: timeOnly, 47: timeOnly,
+}
+
+// ReadStyles reads xl/styles.xml. A workbook without it has no date formats.
+func ReadStyles(zipReader *zip.Reader) (*Styles, error) {
+	return readStyles(archive{Reader: zipReader})
+}
+
+func readStyles(zipReader archive) (*Styles, error) {
+	var styles Styles
+	if zipReader.find("xl/styles.xml") == nil {
+		return &styles, nil
+	}
+	if err := readXMLFromZip(zipReader, "xl/styles.xml", &styles); err != nil {
+		return nil, err
+	}
+
+	custom := make(map[int]string, len(styles.NumFmts.NumFmt))
+	for _, f := range styles.NumFmts.NumFmt {
+		custom[f.ID] = f.Code
+	}
+	styles.kinds = make([]dateKind, len(styles.CellXfs.Xf))
+	for i, xf := range styles.CellXfs.Xf {
+		if code, ok := custom[xf.NumFmtID]; ok {
+			styles.kinds[i] = classifyFormatCode(code)
+		} else {
+			styles.kinds[i] = builtinDateKinds[xf.NumFmtID]
+		}
+	}
+	return &styles, nil
+}
+
+// dateKindOf returns how the cell style with the given s attribute displays numbers
+func (s *Styles) dateKindOf(styleIndex string) dateKind {
+	if s == nil || styleIndex == "" {
+		return notDate
+	}
+	idx, err := strconv.Atoi(styleIndex)
+	if err != nil || idx < 0 || idx >= len(s.kinds) {
+		return notDate
+	}
+	return s.kinds[idx]
+}
+
+// classifyFormatCode inspects a custom number format code for date and time tokens
+func classifyFormatCode(code string) dateKind {
+	// Only the first section (positive numbers) matters
+	code, _, _ = strings.Cut(code, ";")
+
+	var hasDate, hasTime, hasMonthOrMinute bool
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '"': // Quoted literal text
+			if end := strings.IndexByte(code[i+1:], '"'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(code)
+			}
+		case '\\', '_', '*': // Escaped character, padding and fill take the next character
+			i++
+		case '[': // Colors, conditions and locales, except elapsed time like [h] or [mm]
+			end := strings.IndexByte(code[i:], ']')
+			if end < 0 {
+				i = len(code)
+				continue
+			}
+			inner := strings.ToLower(code[i+1 : i+end])
+			if inner != "" && strings.Trim(inner, "hms") == "" {
+				hasTime = true
+			}
+			i += end
+		case 'y', 'Y', 'd', 'D':
+			hasDate = true
+		case 'h', 'H', 's', 'S':
+			hasTime = true
+		case 'm', 'M':
+			hasMonthOrMinute = true
+		}
+	}
+	// "m" is minutes next to hours or seconds, and months otherwise
+	if hasMonthOrMinute && !hasTime {
+		hasDate = true
+	}
+
+	switch {
+	case hasDate && hasTime:
+		return dateWithTime
+	case hasDate:
+		return dateOnly
+	case hasTime:
+		return timeOnly
+	}
+	return notDate
+}
+
+// excelEpoch1900 is day zero of the 1900 date system for serials after Excel's
+// fictitious 1900-02-29 (serial 60); earlier serials count from one day later
+var excelEpoch1900 = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// excelEpoch1904 is day zero of the 1904 date system used by old Mac workbooks
+var excelEpoch1904 = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// serialToTime converts an Excel serial date to a UTC time, rounded to the nearest second
+// (the display precision of Excel time formats)
+func serialToTime(serial float64, date1904 bool) time.Time {
+	epoch := excelEpoch1900
+	switch {
+	case date1904:
+		epoch = excelEpoch1904
+	case serial < 61:
+		epoch = epoch.AddDate(0, 0, 1) // Before the phantom leap day
+	}
+	seconds := math.Round(serial * 86400)
+	return epoch.Add(time.Duration(seconds) * time.Second)
+}
+
+// formatSerialDate renders an Excel serial number as ISO text for the given kind,
+// counting from 1904-01-01 when date1904 is set. Values that are not numbers are returned unchanged.
+func formatSerialDate(value string, kind dateKind, date1904 bool) string {
+	serial, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(serial) || math.IsInf(serial, 0) || serial < 0 {
+		return value
+	}
+
+	t := serialToTime(serial, date1904)
+	switch kind {
+	case dateOnly:
+		return t.Format("2006-01-02")
+	case timeOnly:
+		return t.Format("15:04:05")
+	case dateWithTime:
+		return t.Format("2006-01-02T15:04:05")
+	}
+	return value
+}
@@ -0,0 +1,61 @@
+package xlsx
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDateSystems(t *testing.T) {
+	tests := []struct {
+		file string
+		want map[string]string
+	}{
+		{"dates1900.xlsx", map[string]string{"A1": "1900-01-01", "A2": "2023-03-15", "A3": "2023-03-15T12:00:00"}},
+		{"dates1904.xlsx", map[string]string{"A1": "1904-01-02", "A2": "2027-03-16", "A3": "2027-03-16T12:00:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			cells := cellsByRef(readTestFile(t, tt.file, ReadOptions{ConvertDates: true}).Data)
+			for ref, want := range tt.want {
+				if d := cells[ref]; d.SheetValue != want || d.Type != CellTypeDate {
+					t.Errorf("%s = %q (type %d), want date %q", ref, d.SheetValue, d.Type, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAsTime(t *testing.T) {
+	tests := []struct {
+		file string
+		want map[string]time.Time
+	}{
+		{"dates1900.xlsx", map[string]time.Time{"A2": time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC), "A3": time.Date(2023, 3, 15, 12, 0, 0, 0, time.UTC)}},
+		{"dates1904.xlsx", map[string]time.Time{"A2": time.Date(2027, 3, 16, 0, 0, 0, 0, time.UTC), "A3": time.Date(2027, 3, 16, 12, 0, 0, 0, time.UTC)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			doc, err := Open(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer doc.Close()
+			data, err := doc.ReadSheet(doc.SheetNames()[0]) // Serials, as ConvertDates is not set
+			if err != nil {
+				t.Fatal(err)
+			}
+			cells := cellsByRef(data)
+			for ref, want := range tt.want {
+				if got, ok := cells[ref].AsTime(doc.Workbook.Date1904); !ok || !got.Equal(want) {
+					t.Errorf("%s (%q) = %v, %v, want %v", ref, cells[ref].SheetValue, got, ok, want)
+				}
+			}
+		})
+	}
+
+	text := CellData{SheetValue: "2024-02-29", Type: CellTypeDate}
+	if got, ok := text.AsTime(true); !ok || !got.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ISO date read as %v, %v: the date system only applies to serials", got, ok)
+	}
+}
@@ -0,0 +1,112 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Table is an Excel table (ListObject), a range of a sheet with a header row naming its
+// columns, stored in its own part under xl/tables/
+type Table struct {
+	Name       string    `json:"name"`        // The display name used in formulas, e.g. Table1
+	Sheet      string    `json:"-"`           // Sheet the table is on
+	Path       string    `json:"path"`        // The table part, e.g. xl/tables/table1.xml
+	Ref        string    `json:"ref"`         // Range of the table including its header and totals rows, e.g. A1:C10
+	Range      CellRange `json:"-"`           // Ref, parsed
+	HeaderRows int       `json:"header_rows"` // Number of header rows, 0 when the header row is hidden
+	TotalsRows int       `json:"totals_rows"` // Number of totals rows at the bottom
+	Columns    []string  `json:"columns"`     // Column headers, left to right
+}
+
+// tablePart is the <table> root element of a table part
+type tablePart struct {
+	Name           string `xml:"name,attr"`
+	DisplayName    string `xml:"displayName,attr"`
+	Ref            string `xml:"ref,attr"`
+	HeaderRowCount *int   `xml:"headerRowCount,attr"` // Defaults to 1 when absent
+	TotalsRowCount int    `xml:"totalsRowCount,attr"`
+	TableColumns   struct {
+		TableColumn []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"tableColumn"`
+	} `xml:"tableColumns"`
+}
+
+// ReadSheetTables reads the tables of a sheet, found through the table relationships of
+// its worksheet part
+func ReadSheetTables(zipReader *zip.Reader, sheet WorkbookSheet) ([]Table, error) {
+	return readSheetTables(archive{Reader: zipReader}, sheet)
+}
+
+func readSheetTables(zipReader archive, sheet WorkbookSheet) ([]Table, error) {
+	rels, err := readPartRels(zipReader, sheet.Path)
+	if err != nil {
+		return nil, err
+	}
+	var tables []Table
+	for _, rel := range rels.Relationship {
+		if !strings.HasSuffix(rel.Type, "/table") {
+			continue
+		}
+		tablePath := resolvePartPath(path.Dir(sheet.Path), rel.Target)
+		var part tablePart
+		if err := readXMLFromZip(zipReader, tablePath, &part); err != nil {
+			return nil, fmt.Errorf("table %s: %w", tablePath, err)
+		}
+		cellRange, err := parseRangeReference(strings.ReplaceAll(part.Ref, "$", ""))
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", tablePath, err)
+		}
+		table := Table{
+			Name:       part.DisplayName,
+			Sheet:      sheet.Name,
+			Path:       tablePath,
+			Ref:        part.Ref,
+			Range:      cellRange,
+			HeaderRows: 1,
+			TotalsRows: part.TotalsRowCount,
+		}
+		if table.Name == "" {
+			table.Name = part.Name
+		}
+		if part.HeaderRowCount != nil {
+			table.HeaderRows = *part.HeaderRowCount
+		}
+		for _, column := range part.TableColumns.TableColumn {
+			table.Columns = append(table.Columns, column.Name)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ReadTables reads the tables of every sheet of the workbook, in sheet order. A sheet
+// whose worksheet part is missing has no tables.
+func ReadTables(zipReader *zip.Reader, workbook *Workbook) ([]Table, error) {
+	return readTables(archive{Reader: zipReader}, workbook)
+}
+
+func readTables(zipReader archive, workbook *Workbook) ([]Table, error) {
+	var tables []Table
+	for _, sheet := range workbook.Sheets.Sheet {
+		sheetTables, err := readSheetTables(zipReader, sheet)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", sheet.Name, err)
+		}
+		tables = append(tables, sheetTables...)
+	}
+	return tables, nil
+}
+
+// findTable returns the table with the given name. Table names are unique within a
+// workbook and, as in Excel, compared without regard to case.
+func findTable(tables []Table, name string) (Table, error) {
+	for _, table := range tables {
+		if strings.EqualFold(table.Name, name) {
+			return table, nil
+		}
+	}
+	return Table{}, fmt.Errorf("table %q not found in workbook", name)
+}
@@ -0,0 +1,28 @@
+package xlsx
+
+import "fmt"
+
+// Warning is a problem in a workbook that the reader worked around, e.g. a shared
+// string index past the end of the table
+type Warning struct {
+	Sheet   string
+	Ref     string // Cell reference, empty when the problem is not about one cell
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Ref != "" {
+		return fmt.Sprintf("%s!%s: %s", w.Sheet, w.Ref, w.Message)
+	}
+	if w.Sheet == "" {
+		return w.Message // About the file as a whole
+	}
+	return fmt.Sprintf("%s: %s", w.Sheet, w.Message)
+}
+
+// warn reports a warning through opts.Warn, if set
+func (o ReadOptions) warn(sheet, ref, format string, args ...any) {
+	if o.Warn != nil {
+		o.Warn(Warning{Sheet: sheet, Ref: ref, Message: fmt.Sprintf(format, args...)})
+	}
+}
@@ -0,0 +1,811 @@
+package xlsx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// WriteOptions controls how output files are written
+type WriteOptions struct {
+	NoHeader bool // Leave out the CSV header row
+	QuoteAll bool // Quote every CSV field, not only those that need it
+	Sanitize bool // Neutralize CSV values that a spreadsheet would run as formulas
+
+	Delimiter rune // CSV field separator, ',' when zero
+	CRLF      bool // End CSV lines with \r\n instead of \n
+	BOM       bool // Start CSV files with a UTF-8 byte order mark
+
+	NewlineReplacement string // Replaces line breaks inside CSV values when not empty
+
+	JSONBySheet bool // Write JSON as an object of sheet name -> cells instead of one array
+	Pretty      bool // Indent JSON output
+	JSONNull    bool // Write the value of cells filled in by -dense as null rather than ""
+	JSONMerges  bool // Write JSON as {"cells": ..., "merges": [{"sheet", "range"}, ...]}
+	JSONArrays  bool // Write JSON cells as rows, arrays of values ordered by column, see writeJSONRows
+
+	ColumnLetters bool // Add the column as letters (ColumnLetter, column_letter) to CSV and JSON output
+	StyleIDs      bool // Add the cell's StyleID (StyleID, style_id) to CSV and JSON output
+
+	AvroCodec string // Avro block compression: deflate (default), snappy or null
+
+	SourceColumn bool // Add the SourceFile column, set by WriteFile when any row has a SourceFile
+}
+
+// RowWriter is an output sink receiving the cells one at a time. WriteHeader is called
+// once before the first row, and Close once at the end to finish the output, also after
+// a failed write.
+type RowWriter interface {
+	WriteHeader() error
+	WriteRow(d CellData) error
+	Close() error
+}
+
+// RowWriterFactory creates the RowWriter of one output format for targetPath
+type RowWriterFactory func(targetPath string, opts WriteOptions) (RowWriter, error)
+
+// rowWriters maps each output format to its factory
+var rowWriters = make(map[string]RowWriterFactory)
+
+// outputFormats lists the formats of rowWriters in the order RegisterRowWriter added them
+var outputFormats []string
+
+// RegisterRowWriter makes a format available to WriteFile and NewRowWriter, and so to
+// -format and target extensions, e.g. a sink streaming to a database. Registering a format
+// again replaces its factory.
+func RegisterRowWriter(format string, factory RowWriterFactory) {
+	format = strings.ToLower(format)
+	if _, exists := rowWriters[format]; !exists {
+		outputFormats = append(outputFormats, format)
+	}
+	rowWriters[format] = factory
+}
+
+func init() {
+	RegisterRowWriter("csv", newCSVWriter)
+	RegisterRowWriter("json", newJSONWriter)
+	RegisterRowWriter("parquet", func(targetPath string, _ WriteOptions) (RowWriter, error) {
+		return NewParquetSink(targetPath, 0)
+	})
+	RegisterRowWriter("xlsx", newXLSXWriter)
+	RegisterRowWriter("avro", newAvroWriter)
+}
+
+// OutputFormats returns the registered output formats, the built-in ones first
+func OutputFormats() []string {
+	return slices.Clone(outputFormats)
+}
+
+// NewRowWriter returns the RowWriter of the registered format for targetPath
+func NewRowWriter(format, targetPath string, opts WriteOptions) (RowWriter, error) {
+	factory, ok := rowWriters[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q. Use %s", format, strings.Join(outputFormats, ", "))
+	}
+	return factory(targetPath, opts)
+}
+
+// WriteFile writes the data to targetPath through the RowWriter registered for the format
+func WriteFile(data []CellData, targetPath, format string, opts WriteOptions) error {
+	opts.SourceColumn = hasSourceFile(data)
+	w, err := NewRowWriter(format, targetPath, opts)
+	if err != nil {
+		return err
+	}
+	if err := writeRows(w, data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// writeRows sends the header and then every cell to w
+func writeRows(w RowWriter, data []CellData) error {
+	if err := w.WriteHeader(); err != nil {
+		return err
+	}
+	for _, d := range data {
+		if err := w.WriteRow(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newlineReplacer rewrites \r\n, \r and \n line breaks in a value to the given token
+func newlineReplacer(token string) *strings.Replacer {
+	return strings.NewReplacer("\r\n", token, "\r", token, "\n", token)
+}
+
+// sanitizeCSVValue prefixes values starting with a formula trigger (=, +, -, @, tab or
+// carriage return) with an apostrophe, following the OWASP CSV injection guidance.
+// Plain numbers such as -5 cannot run as formulas and are left as they are.
+func sanitizeCSVValue(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) || isNumericValue(value) {
+		return value
+	}
+	return "'" + value
+}
+
+// csvRecordWriter is the part of csv.Writer used by csvWriter
+type csvRecordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// quoteAllWriter writes CSV records with every field quoted, so empty strings are
+// distinguishable from missing values and no field is read back unquoted
+type quoteAllWriter struct {
+	w       *bufio.Writer
+	comma   rune
+	useCRLF bool
+	err     error
+}
+
+func newQuoteAllWriter(w io.Writer, comma rune, useCRLF bool) *quoteAllWriter {
+	return &quoteAllWriter{w: bufio.NewWriter(w), comma: comma, useCRLF: useCRLF}
+}
+
+// Write writes one record, doubling any quotes inside the fields
+func (q *quoteAllWriter) Write(record []string) error {
+	if q.err != nil {
+		return q.err
+	}
+	for i, field := range record {
+		if i > 0 {
+			q.w.WriteRune(q.comma)
+		}
+		q.w.WriteByte('"')
+		if q.useCRLF {
+			// Same as csv.Writer: bare newlines inside fields become \r\n too
+			field = strings.ReplaceAll(strings.ReplaceAll(field, "\r\n", "\n"), "\n", "\r\n")
+		}
+		q.w.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		q.w.WriteByte('"')
+	}
+	if q.useCRLF {
+		_, q.err = q.w.WriteString("\r\n")
+	} else {
+		_, q.err = q.w.WriteString("\n")
+	}
+	return q.err
+}
+
+func (q *quoteAllWriter) Flush() {
+	if err := q.w.Flush(); err != nil && q.err == nil {
+		q.err = err
+	}
+}
+
+func (q *quoteAllWriter) Error() error {
+	return q.err
+}
+
+// StdoutTarget is the target path that writes to standard output
+const StdoutTarget = "-"
+
+// createOutput creates the output file, or returns standard output for "-"
+func createOutput(targetPath string) (io.WriteCloser, error) {
+	if targetPath == StdoutTarget {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(targetPath)
+}
+
+// nopWriteCloser keeps writers from closing standard output
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// hasSourceFile reports whether any row carries a SourceFile
+func hasSourceFile(data []CellData) bool {
+	for _, d := range data {
+		if d.SourceFile != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitSheets splits the cells by SheetName, keeping the sheets in first-seen order, e.g.
+// to write each sheet to a file of its own
+func SplitSheets(data []CellData) [][]CellData {
+	var sheets [][]CellData
+	for _, sheet := range groupSheets(data, false) {
+		sheets = append(sheets, sheet.cells)
+	}
+	return sheets
+}
+
+// csvWriter writes the cells as CSV, one record per cell
+type csvWriter struct {
+	path     string
+	file     io.WriteCloser
+	records  csvRecordWriter
+	opts     WriteOptions
+	newlines *strings.Replacer // Set with opts.NewlineReplacement
+}
+
+// newCSVWriter creates the CSV file at targetPath
+func newCSVWriter(targetPath string, opts WriteOptions) (RowWriter, error) {
+	file, err := createOutput(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CSV file: %w", err)
+	}
+
+	// Excel on Windows only detects UTF-8 CSV files by their byte order mark
+	if opts.BOM {
+		if _, err := io.WriteString(file, "\uFEFF"); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("error writing CSV file: %w", err)
+		}
+	}
+
+	comma := opts.Delimiter
+	if comma == 0 {
+		comma = ','
+	}
+	w := &csvWriter{path: targetPath, file: file, opts: opts}
+	if opts.QuoteAll {
+		w.records = newQuoteAllWriter(file, comma, opts.CRLF)
+	} else {
+		csvWriter := csv.NewWriter(file)
+		csvWriter.Comma = comma
+		csvWriter.UseCRLF = opts.CRLF
+		w.records = csvWriter
+	}
+	if opts.NewlineReplacement != "" {
+		w.newlines = newlineReplacer(opts.NewlineReplacement)
+	}
+	return w, nil
+}
+
+func (w *csvWriter) WriteHeader() error {
+	if w.opts.NoHeader {
+		return nil
+	}
+	header := []string{"SheetName", "RowNumber", "ColumnNumber", "SheetValue", "Merged", "MergedRange"}
+	if w.opts.ColumnLetters {
+		header = slices.Insert(header, 3, "ColumnLetter")
+	}
+	if w.opts.StyleIDs {
+		header = append(header, "StyleID")
+	}
+	// The SourceFile column is only present when rows come from several workbooks
+	if w.opts.SourceColumn {
+		header = append(header, "SourceFile")
+	}
+	return w.records.Write(header)
+}
+
+func (w *csvWriter) WriteRow(d CellData) error {
+	if w.newlines != nil {
+		d.SheetValue = w.newlines.Replace(d.SheetValue)
+	}
+	if w.opts.Sanitize {
+		d.SheetName = sanitizeCSVValue(d.SheetName)
+		d.SheetValue = sanitizeCSVValue(d.SheetValue)
+		d.SourceFile = sanitizeCSVValue(d.SourceFile)
+	}
+	record := []string{d.SheetName, strconv.Itoa(int(d.RowNumber)), strconv.Itoa(int(d.ColumnNumber)), d.SheetValue, strconv.FormatBool(d.Merged), d.MergedRange}
+	if w.opts.ColumnLetters {
+		record = slices.Insert(record, 3, IndexToColumn(d.ColumnNumber))
+	}
+	if w.opts.StyleIDs {
+		record = append(record, strconv.Itoa(int(d.StyleID)))
+	}
+	if w.opts.SourceColumn {
+		record = append(record, d.SourceFile)
+	}
+	return w.records.Write(record)
+}
+
+func (w *csvWriter) Close() error {
+	defer w.file.Close()
+
+	// csv.Writer buffers and remembers the first write error, so a full disk only shows up here
+	w.records.Flush()
+	if err := w.records.Error(); err != nil {
+		return fmt.Errorf("error writing CSV file: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("error closing CSV file: %w", err)
+	}
+	slog.Info("CSV output written", "path", w.path)
+	return nil
+}
+
+// jsonWriter writes the cells as a JSON array as they come, holding none of them. Grouping
+// them by sheet or into rows, or listing the merged ranges, needs all of them, so with
+// opts.JSONBySheet, opts.JSONArrays or opts.JSONMerges the cells are collected and
+// written on Close instead.
+type jsonWriter struct {
+	path     string
+	file     io.WriteCloser
+	buffered *bufio.Writer
+	opts     WriteOptions
+	stream   *jsonArrayEncoder // nil when the cells are collected
+	cells    []CellData
+}
+
+// newJSONWriter creates the JSON file at targetPath
+func newJSONWriter(targetPath string, opts WriteOptions) (RowWriter, error) {
+	file, err := createOutput(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating JSON file: %w", err)
+	}
+	w := &jsonWriter{path: targetPath, file: file, buffered: bufio.NewWriterSize(file, 128*1024), opts: opts}
+	if !opts.JSONBySheet && !opts.JSONArrays && !opts.JSONMerges {
+		w.stream = &jsonArrayEncoder{w: w.buffered, opts: opts}
+	}
+	return w, nil
+}
+
+func (w *jsonWriter) WriteHeader() error { return nil }
+
+func (w *jsonWriter) WriteRow(d CellData) error {
+	if w.stream != nil {
+		if err := w.stream.add(d); err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+		return nil
+	}
+	w.cells = append(w.cells, d)
+	return nil
+}
+
+func (w *jsonWriter) Close() error {
+	defer w.file.Close()
+
+	buffered := w.buffered
+	var err error
+	switch {
+	case w.stream != nil:
+		err = w.stream.end()
+	case w.opts.JSONMerges:
+		err = writeJSONWithMerges(buffered, w.cells, w.opts)
+	default:
+		err = writeJSONCells(buffered, w.cells, w.opts, "")
+	}
+	if err != nil {
+		return fmt.Errorf("error encoding JSON: %w", err)
+	}
+	buffered.WriteString("\n")
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("error writing JSON file: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("error closing JSON file: %w", err)
+	}
+	slog.Info("JSON output written", "path", w.path)
+	return nil
+}
+
+// writeJSONCells writes the cells as an array, or as an object per sheet with opts.JSONBySheet
+func writeJSONCells(w *bufio.Writer, data []CellData, opts WriteOptions, prefix string) error {
+	switch {
+	case opts.JSONBySheet:
+		return writeJSONBySheet(w, data, opts, prefix)
+	case opts.JSONArrays:
+		return writeJSONRows(w, data, opts, prefix)
+	}
+	return writeJSONArray(w, data, opts, prefix)
+}
+
+// jsonMerge is an entry of the "merges" section
+type jsonMerge struct {
+	Sheet string `json:"sheet"`
+	Range string `json:"range"`
+}
+
+// writeJSONWithMerges writes {"cells": ..., "merges": [...]}, listing the merged ranges of
+// each sheet in the order groupSheets finds them in the cells' MergedRange
+func writeJSONWithMerges(w *bufio.Writer, data []CellData, opts WriteOptions) error {
+	merges := []jsonMerge{}
+	for _, sheet := range groupSheets(data, false) {
+		for _, r := range sheet.merges {
+			merges = append(merges, jsonMerge{Sheet: sheet.name, Range: r})
+		}
+	}
+
+	sep, indent := ":", ""
+	if opts.Pretty {
+		sep, indent = ": ", "\n  "
+	}
+	w.WriteString("{" + indent + `"cells"` + sep)
+	if err := writeJSONCells(w, data, opts, "  "); err != nil {
+		return err
+	}
+	w.WriteString("," + indent + `"merges"` + sep + "[")
+	for i, m := range merges {
+		encoded, err := json.Marshal(m)
+		if opts.Pretty {
+			encoded, err = json.MarshalIndent(m, "    ", "  ")
+		}
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if opts.Pretty {
+			w.WriteString("\n    ")
+		}
+		w.Write(encoded)
+	}
+	if opts.Pretty && len(merges) > 0 {
+		w.WriteString("\n  ")
+	}
+	w.WriteByte(']')
+	if opts.Pretty {
+		w.WriteByte('\n')
+	}
+	return w.WriteByte('}')
+}
+
+// jsonAbsentCell encodes a cell with a null sheet_value; the outer field shadows CellData's
+type jsonAbsentCell struct {
+	CellData
+	SheetValue   *string `json:"sheet_value"`
+	ColumnLetter string  `json:"column_letter,omitempty"`
+	StyleID      *int32  `json:"style_id,omitempty"`
+}
+
+// jsonExtraCell encodes a cell with the fields added by opts.ColumnLetters and opts.StyleIDs
+type jsonExtraCell struct {
+	CellData
+	ColumnLetter string `json:"column_letter,omitempty"`
+	StyleID      *int32 `json:"style_id,omitempty"`
+}
+
+// writeJSONArray writes the cells as a JSON array, see jsonArrayEncoder
+func writeJSONArray(w *bufio.Writer, cells []CellData, opts WriteOptions, prefix string) error {
+	e := &jsonArrayEncoder{w: w, opts: opts, prefix: prefix}
+	for _, cell := range cells {
+		if err := e.add(cell); err != nil {
+			return err
+		}
+	}
+	return e.end()
+}
+
+// jsonArrayEncoder writes cells as the elements of a JSON array, encoding one cell at a
+// time so the output is never held in memory as a whole. With opts.Pretty set, each cell
+// is indented by two spaces, after prefix on every line but the first.
+type jsonArrayEncoder struct {
+	w        *bufio.Writer
+	opts     WriteOptions
+	prefix   string
+	cells    int // Cells written so far
+	started  bool
+	indented bytes.Buffer
+}
+
+// add writes the next cell, after the opening bracket for the first one
+func (e *jsonArrayEncoder) add(cell CellData) error {
+	opts := e.opts
+	var encoded []byte
+	var err error
+	letter := ""
+	if opts.ColumnLetters {
+		letter = IndexToColumn(cell.ColumnNumber)
+	}
+	var styleID *int32
+	if opts.StyleIDs {
+		styleID = &cell.StyleID
+	}
+	switch {
+	case opts.JSONNull && cell.Filled:
+		encoded, err = json.Marshal(jsonAbsentCell{CellData: cell, ColumnLetter: letter, StyleID: styleID})
+	case opts.ColumnLetters || opts.StyleIDs:
+		encoded, err = json.Marshal(jsonExtraCell{CellData: cell, ColumnLetter: letter, StyleID: styleID})
+	default:
+		encoded, err = json.Marshal(cell)
+	}
+	if err != nil {
+		return err
+	}
+	if !e.started {
+		e.w.WriteByte('[')
+		e.started = true
+	}
+	if e.cells > 0 {
+		e.w.WriteByte(',')
+	}
+	if opts.Pretty {
+		e.w.WriteString("\n" + e.prefix + "  ")
+		e.indented.Reset()
+		json.Indent(&e.indented, encoded, e.prefix+"  ", "  ")
+		encoded = e.indented.Bytes()
+	}
+	e.cells++
+	_, err = e.w.Write(encoded)
+	return err
+}
+
+// end closes the array, writing [] when no cell was added
+func (e *jsonArrayEncoder) end() error {
+	if !e.started {
+		e.w.WriteByte('[')
+	}
+	if e.opts.Pretty && e.cells > 0 {
+		e.w.WriteString("\n" + e.prefix)
+	}
+	return e.w.WriteByte(']')
+}
+
+// writeJSONRows writes the cells of one sheet as an array of rows, each an array of the
+// values ordered by column, e.g. [["A1","B1"],["A2","B2"]]. The rows fill the range from the
+// sheet's top-left to its bottom-right cell: positions without a cell, whole rows included,
+// are "", or null with opts.JSONNull, as are the cells -dense added. The rows of several
+// sheets, or of the same sheet in several files, cannot be told apart in one grid, so they
+// are rejected; writeJSONBySheet gives each sheet a grid of its own. With opts.Pretty set,
+// each row is on a line of its own.
+func writeJSONRows(w *bufio.Writer, cells []CellData, opts WriteOptions, prefix string) error {
+	sheets := groupSheets(cells, true)
+	switch {
+	case len(sheets) > 1 && opts.JSONBySheet:
+		return fmt.Errorf("sheet %s of several files cannot be written as one grid of rows: write the files to separate outputs", sheets[0].name)
+	case len(sheets) > 1:
+		return fmt.Errorf("the rows of %d sheets cannot be told apart in one array: add -json-by-sheet, or {sheet} to -name-template", len(sheets))
+	}
+	w.WriteByte('[')
+	rows := 0
+	writeRow := func(values []any) error {
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		if rows > 0 {
+			w.WriteByte(',')
+		}
+		if opts.Pretty {
+			w.WriteString("\n" + prefix + "  ")
+		}
+		rows++
+		_, err = w.Write(encoded)
+		return err
+	}
+	for _, sheet := range sheets {
+		sheetCells := slices.DeleteFunc(slices.Clone(sheet.cells), func(d CellData) bool {
+			return d.RowNumber < 1 || d.ColumnNumber < 1 // No position in the grid
+		})
+		if len(sheetCells) == 0 {
+			continue
+		}
+		sortCells(sheetCells)
+		bounds := cellBounds(sheetCells)
+		width := int(bounds.EndCol - bounds.StartCol + 1)
+		var gap any = ""
+		if opts.JSONNull {
+			gap = nil
+		}
+		values := make([]any, width)
+		next := 0 // Index of the first cell not yet placed
+		for row := bounds.StartRow; row <= bounds.EndRow; row++ {
+			for i := range values {
+				values[i] = gap
+			}
+			for ; next < len(sheetCells) && sheetCells[next].RowNumber == row; next++ {
+				if d := sheetCells[next]; !(opts.JSONNull && d.Filled) {
+					values[d.ColumnNumber-bounds.StartCol] = d.SheetValue
+				}
+			}
+			if err := writeRow(values); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.Pretty && rows > 0 {
+		w.WriteString("\n" + prefix)
+	}
+	return w.WriteByte(']')
+}
+
+// writeJSONBySheet writes {"Sheet1": [...], "Sheet2": [...]} with the sheets in the order
+// they appear in the data, i.e. workbook order. A map would lose that order, so the object
+// is written one key at a time.
+func writeJSONBySheet(w *bufio.Writer, data []CellData, opts WriteOptions, prefix string) error {
+	pretty := opts.Pretty
+	w.WriteByte('{')
+	sheets := groupSheets(data, false)
+	for i, sheet := range sheets {
+		key, err := json.Marshal(sheet.name)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if pretty {
+			w.WriteString("\n" + prefix + "  ")
+			w.Write(key)
+			w.WriteString(": ")
+		} else {
+			w.Write(key)
+			w.WriteByte(':')
+		}
+		write := writeJSONArray
+		if opts.JSONArrays {
+			write = writeJSONRows
+		}
+		if err := write(w, sheet.cells, opts, prefix+"  "); err != nil {
+			return err
+		}
+	}
+	if pretty && len(sheets) > 0 {
+		w.WriteString("\n" + prefix)
+	}
+	return w.WriteByte('}')
+}
+
+// cellOverhead estimates the fixed in-memory size of a CellData, without its string
+// contents: four string headers of 16 bytes and the numeric fields, with padding, on
+// 64-bit platforms. It only sizes -max-mem row groups, so it need not be exact.
+const cellOverhead = 88
+
+// estimatedSize approximates the memory a cell takes while buffered
+func (d CellData) estimatedSize() int64 {
+	return cellOverhead + int64(len(d.SheetName)+len(d.SheetValue)+len(d.MergedRange)+len(d.SourceFile))
+}
+
+// parquetCell is the Parquet row of a cell. SheetValue is an optional column: the positions
+// -dense filled, where the sheet has no cell, are written as NULL, so engines such as DuckDB
+// or Spark see real nulls, while cells that exist but are empty keep "". The column names
+// are those of CellData, so files written before SheetValue became optional read back too.
+type parquetCell struct {
+	SheetName    string
+	RowNumber    int32
+	ColumnNumber int32
+	SheetValue   *string `parquet:",optional"`
+	Merged       bool
+	MergedRange  string
+	SourceFile   string `parquet:",optional"`
+}
+
+// newParquetCell returns the Parquet row of d
+func newParquetCell(d *CellData) parquetCell {
+	row := parquetCell{SheetName: d.SheetName, RowNumber: d.RowNumber, ColumnNumber: d.ColumnNumber, Merged: d.Merged, MergedRange: d.MergedRange, SourceFile: d.SourceFile}
+	if !d.Filled {
+		row.SheetValue = &d.SheetValue
+	}
+	return row
+}
+
+// cellData returns the cell a Parquet row was written from; a NULL value is a filled position
+func (row parquetCell) cellData() CellData {
+	d := CellData{SheetName: row.SheetName, RowNumber: row.RowNumber, ColumnNumber: row.ColumnNumber, Merged: row.Merged, MergedRange: row.MergedRange, SourceFile: row.SourceFile, Filled: row.SheetValue == nil}
+	if row.SheetValue != nil {
+		d.SheetValue = *row.SheetValue
+	}
+	return d
+}
+
+// ParquetSink writes cells to a Parquet file incrementally. With a maxBytes limit, the
+// buffered cells are flushed as a row group whenever their estimated size reaches it, so
+// memory stays bounded however many cells are written; without one, everything is
+// written as a single row group on Close. It is the RowWriter of the parquet format.
+type ParquetSink struct {
+	path     string
+	file     io.WriteCloser
+	writer   *parquet.GenericWriter[parquetCell]
+	rows     []parquetCell // Reused to convert the cells of each write
+	maxBytes int64
+	buffered int64      // Estimated size of the cells written since the last flush
+	pending  []CellData // Rows from WriteRow, handed to Write in batches
+	err      error      // First write error, returned again by Close
+}
+
+// parquetRowBatch is the number of rows WriteRow collects before passing them on
+const parquetRowBatch = 1024
+
+// NewParquetSink creates targetPath and prepares a writer for it
+func NewParquetSink(targetPath string, maxBytes int64) (*ParquetSink, error) {
+	// Create the target file
+	file, err := createOutput(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Parquet file: %w", err)
+	}
+
+	// Create a new ZSTD codec instance with strong compression
+	zstdCodec := &zstd.Codec{
+		Level:       zstd.SpeedBestCompression, // Set to best compression level
+		Concurrency: 4,                         // Number of cores to use for encoding
+	}
+
+	// Define the Parquet writer with strong ZSTD compression, dictionary encoding, and row group size
+	writer := parquet.NewGenericWriter[parquetCell](file,
+		parquet.Compression(zstdCodec),            // Use the ZSTD codec with strong compression
+		parquet.MaxRowsPerRowGroup(128*1024*1024), // Reduce row group size to 8 MB for better compression
+	)
+	return &ParquetSink{path: targetPath, file: file, writer: writer, maxBytes: maxBytes}, nil
+}
+
+func (s *ParquetSink) WriteHeader() error { return nil } // The schema is written with the footer
+
+func (s *ParquetSink) WriteRow(d CellData) error {
+	s.pending = append(s.pending, d)
+	if len(s.pending) < parquetRowBatch {
+		return nil
+	}
+	err := s.Write(s.pending)
+	s.pending = s.pending[:0]
+	return err
+}
+
+// Write adds cells to the file, flushing a row group each time maxBytes is reached
+func (s *ParquetSink) Write(cells []CellData) error {
+	if s.err == nil {
+		s.err = s.write(cells)
+	}
+	return s.err
+}
+
+func (s *ParquetSink) write(cells []CellData) error {
+	s.rows = s.rows[:0]
+	for i := range cells {
+		s.rows = append(s.rows, newParquetCell(&cells[i]))
+	}
+	if s.maxBytes <= 0 {
+		if _, err := s.writer.Write(s.rows); err != nil {
+			return fmt.Errorf("error writing data to Parquet file: %w", err)
+		}
+		return nil
+	}
+	start := 0
+	for i, d := range cells {
+		s.buffered += d.estimatedSize()
+		if s.buffered < s.maxBytes {
+			continue
+		}
+		if _, err := s.writer.Write(s.rows[start : i+1]); err != nil {
+			return fmt.Errorf("error writing data to Parquet file: %w", err)
+		}
+		if err := s.writer.Flush(); err != nil {
+			return fmt.Errorf("error flushing Parquet row group: %w", err)
+		}
+		start, s.buffered = i+1, 0
+	}
+	if _, err := s.writer.Write(s.rows[start:]); err != nil {
+		return fmt.Errorf("error writing data to Parquet file: %w", err)
+	}
+	return nil
+}
+
+// Close writes the remaining cells and the footer, and closes the file
+func (s *ParquetSink) Close() error {
+	defer s.file.Close()
+
+	if len(s.pending) > 0 {
+		s.Write(s.pending)
+		s.pending = nil
+	}
+	if s.err != nil {
+		return s.err
+	}
+
+	// Ensure the writer is properly closed (flushes buffers and writes the footer)
+	if err := s.writer.Close(); err != nil {
+		return fmt.Errorf("error closing Parquet writer: %w", err)
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("error closing Parquet file: %w", err)
+	}
+
+	slog.Info("Parquet output written", "path", s.path)
+	return nil
+}
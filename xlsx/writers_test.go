@@ -0,0 +1,169 @@
+package xlsx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeParquetFile writes cells through a ParquetSink, in batches of batch cells
+func writeParquetFile(t *testing.T, path string, cells []CellData, maxBytes int64, batch int) {
+	t.Helper()
+	sink, err := NewParquetSink(path, maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for chunk := range slices.Chunk(cells, batch) {
+		if err := sink.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParquetSinkSpilling(t *testing.T) {
+	var cells []CellData
+	for row := int32(1); row <= 100; row++ {
+		for col := int32(1); col <= 4; col++ {
+			d := CellData{SheetName: "Sheet1", RowNumber: row, ColumnNumber: col, SheetValue: fmt.Sprintf("r%dc%d", row, col)}
+			if col == 4 && row%7 == 0 {
+				d = CellData{SheetName: "Sheet1", RowNumber: row, ColumnNumber: col, Filled: true}
+			}
+			cells = append(cells, d)
+		}
+	}
+	want := filepath.Join(t.TempDir(), "whole.parquet")
+	writeParquetFile(t, want, cells, 0, len(cells))
+	wantData, err := readParquet(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(wantData, cells) {
+		t.Fatal("cells written in one row group do not read back unchanged")
+	}
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		batch    int
+		cells    int // Cells written, 0 for all
+	}{
+		// Few row groups per case, as each is slow to write under the race detector
+		{"one row group per cell", 1, 4, 8},
+		{"small row groups", 8000, 50, 0},
+		{"limit larger than the data", 1 << 30, 50, 0},
+		{"limit within a batch", 10000, 200, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := wantData
+			if tt.cells > 0 {
+				want = wantData[:tt.cells]
+			}
+			path := filepath.Join(t.TempDir(), "spilled.parquet")
+			writeParquetFile(t, path, cells[:len(want)], tt.maxBytes, tt.batch)
+			got, err := readParquet(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("spilled output differs from the output written in one row group")
+			}
+		})
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+	cells := []CellData{
+		{SheetName: "Sheet1", RowNumber: 1, ColumnNumber: 1, SheetValue: "a"},
+		{SheetName: "Sheet1", RowNumber: 2, ColumnNumber: 2, SheetValue: "1.5"},
+	}
+	compact, err := json.Marshal(cells)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pretty, err := json.MarshalIndent(cells, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		cells  []CellData
+		pretty bool
+		want   string
+	}{
+		{"compact", cells, false, string(compact)},
+		{"pretty", cells, true, string(pretty)},
+		{"no cells", nil, false, "[]"},
+		{"no cells pretty", nil, true, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.json")
+			w, err := newJSONWriter(path, WriteOptions{Pretty: tt.pretty})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.(*jsonWriter).stream == nil {
+				t.Fatal("plain JSON output is collected, not streamed")
+			}
+			for _, d := range tt.cells {
+				if err := w.WriteRow(d); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(string(got)) != tt.want {
+				t.Errorf("wrote %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONRows(t *testing.T) {
+	sheet1 := []CellData{
+		{SheetName: "Sheet1", RowNumber: 1, ColumnNumber: 1, SheetValue: "a"},
+		{SheetName: "Sheet1", RowNumber: 3, ColumnNumber: 2, SheetValue: "b"},
+	}
+	sheet2 := []CellData{{SheetName: "Sheet2", RowNumber: 1, ColumnNumber: 1, SheetValue: "c"}}
+	tests := []struct {
+		name    string
+		cells   []CellData
+		want    string
+		wantErr bool
+	}{
+		{"one sheet", sheet1, `[["a",""],["",""],["","b"]]`, false},
+		{"two sheets", append(slices.Clone(sheet1), sheet2...), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.json")
+			err := WriteFile(tt.cells, path, "json", WriteOptions{JSONArrays: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteFile error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(string(got)) != tt.want {
+				t.Errorf("wrote %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxMainNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelNS  = relationshipsNS
+)
+
+// xlsxSheet collects the cells and merged ranges of one output sheet
+type xlsxSheet struct {
+	name   string
+	source string // SourceFile of the cells, set when several workbooks are combined
+	cells  []CellData
+	merges []string
+}
+
+// groupSheets splits the data by SheetName, keeping the sheets in first-seen order. With
+// bySource set, cells of different SourceFiles stay apart, so the same sheet name in two
+// merged workbooks gives two sheets rather than one with overlapping cells and merged ranges.
+func groupSheets(data []CellData, bySource bool) []*xlsxSheet {
+	type sheetKey struct{ source, name string }
+	var sheets []*xlsxSheet
+	byKey := make(map[sheetKey]*xlsxSheet)
+	mergeSeen := make(map[*xlsxSheet]map[string]bool)
+	for _, d := range data {
+		name := d.SheetName
+		if name == "" {
+			name = "Sheet1"
+		}
+		key := sheetKey{name: name}
+		if bySource {
+			key.source = d.SourceFile
+		}
+		sheet, ok := byKey[key]
+		if !ok {
+			sheet = &xlsxSheet{name: name, source: d.SourceFile}
+			byKey[key] = sheet
+			mergeSeen[sheet] = make(map[string]bool)
+			sheets = append(sheets, sheet)
+		}
+		sheet.cells = append(sheet.cells, d)
+		if d.Merged && d.MergedRange != "" && !mergeSeen[sheet][d.MergedRange] {
+			mergeSeen[sheet][d.MergedRange] = true
+			sheet.merges = append(sheet.merges, d.MergedRange)
+		}
+	}
+	return sheets
+}
+
+// maxSheetNameLength is the longest sheet name Excel accepts
+const maxSheetNameLength = 31
+
+// excelSheetNames returns a name for each sheet that Excel opens without repairing the
+// workbook: at most 31 characters, none of : \ / ? * [ ], no leading or trailing
+// apostrophe, and unique regardless of case. Repeated names get a suffix, as in Data (2).
+func excelSheetNames(sheets []*xlsxSheet) []string {
+	names := make([]string, len(sheets))
+	used := make(map[string]bool)
+	for i, sheet := range sheets {
+		base := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`:\/?*[]`, r) || r < 0x20 {
+				return '_'
+			}
+			return r
+		}, sheet.name)
+		base = strings.Trim(base, "'")
+		if base == "" || strings.EqualFold(base, "History") { // History is reserved by Excel
+			base = fmt.Sprintf("Sheet%d", i+1)
+		}
+		name := truncateRunes(base, maxSheetNameLength)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			name = truncateRunes(base, maxSheetNameLength-len(suffix)) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		if name != sheet.name {
+			slog.Info("sheet renamed for XLSX output", "sheet", sheet.name, "source", sheet.source, "name", name)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// truncateRunes shortens s to at most n characters
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// xlsxWriter collects the cells and rebuilds a workbook from them on Close, one worksheet
+// per SheetName, with a shared-string table for text values and the merged ranges found
+// in MergedRange
+type xlsxWriter struct {
+	path  string
+	file  io.WriteCloser
+	cells []CellData
+}
+
+// newXLSXWriter creates the XLSX file at targetPath
+func newXLSXWriter(targetPath string, _ WriteOptions) (RowWriter, error) {
+	file, err := createOutput(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating XLSX file: %w", err)
+	}
+	return &xlsxWriter{path: targetPath, file: file}, nil
+}
+
+func (w *xlsxWriter) WriteHeader() error { return nil }
+
+func (w *xlsxWriter) WriteRow(d CellData) error {
+	w.cells = append(w.cells, d)
+	return nil
+}
+
+func (w *xlsxWriter) Close() error {
+	file, targetPath := w.file, w.path
+	defer file.Close()
+
+	buffered := bufio.NewWriterSize(file, 128*1024)
+	zipWriter := zip.NewWriter(buffered)
+	sheets := groupSheets(w.cells, true)
+	if len(sheets) == 0 {
+		sheets = []*xlsxSheet{{name: "Sheet1"}} // A workbook needs at least one sheet
+	}
+	for i, name := range excelSheetNames(sheets) {
+		sheets[i].name = name
+	}
+
+	// Shared strings are collected while the sheets are written, so the table goes last
+	sst := newSharedStringTable()
+	for i, sheet := range sheets {
+		if err := writeZipPart(zipWriter, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), func(w *bufio.Writer) error {
+			return writeWorksheetXML(w, sheet, sst)
+		}); err != nil {
+			return fmt.Errorf("error writing sheet %s: %w", sheet.name, err)
+		}
+	}
+
+	parts := []struct {
+		name  string
+		write func(w *bufio.Writer) error
+	}{
+		{"[Content_Types].xml", func(w *bufio.Writer) error { return writeContentTypesXML(w, len(sheets)) }},
+		{"_rels/.rels", writeRootRelsXML},
+		{"xl/workbook.xml", func(w *bufio.Writer) error { return writeWorkbookXML(w, sheets) }},
+		{"xl/_rels/workbook.xml.rels", func(w *bufio.Writer) error { return writeWorkbookRelsXML(w, len(sheets)) }},
+		{"xl/sharedStrings.xml", sst.writeXML},
+	}
+	for _, part := range parts {
+		if err := writeZipPart(zipWriter, part.name, part.write); err != nil {
+			return fmt.Errorf("error writing %s: %w", part.name, err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("error finishing XLSX file: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("error writing XLSX file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error closing XLSX file: %w", err)
+	}
+	slog.Info("XLSX output written", "path", targetPath)
+	return nil
+}
+
+// writeZipPart adds a deflated part to the archive and fills it through write
+func writeZipPart(zipWriter *zip.Writer, name string, write func(w *bufio.Writer) error) error {
+	part, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(part, 64*1024)
+	if err := write(w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// sharedStringTable assigns each distinct string an index in xl/sharedStrings.xml
+type sharedStringTable struct {
+	index map[string]int
+	items []string
+	count int // Total references, written as the sst count attribute
+}
+
+func newSharedStringTable() *sharedStringTable {
+	return &sharedStringTable{index: make(map[string]int)}
+}
+
+// add returns the index of s, adding it to the table if needed
+func (t *sharedStringTable) add(s string) int {
+	t.count++
+	if idx, ok := t.index[s]; ok {
+		return idx
+	}
+	idx := len(t.items)
+	t.index[s] = idx
+	t.items = append(t.items, s)
+	return idx
+}
+
+func (t *sharedStringTable) writeXML(w *bufio.Writer) error {
+	fmt.Fprintf(w, `%s<sst xmlns="%s" count="%d" uniqueCount="%d">`, xml.Header, xlsxMainNS, t.count, len(t.items))
+	for _, item := range t.items {
+		// Leading or trailing spaces are dropped by Excel unless marked as preserved
+		if strings.TrimSpace(item) != item {
+			w.WriteString(`<si><t xml:space="preserve">`)
+		} else {
+			w.WriteString(`<si><t>`)
+		}
+		if err := xml.EscapeText(w, []byte(item)); err != nil {
+			return err
+		}
+		w.WriteString(`</t></si>`)
+	}
+	_, err := w.WriteString(`</sst>`)
+	return err
+}
+
+// writeWorksheetXML writes the sheet's cells ordered by row and column
+func writeWorksheetXML(w *bufio.Writer, sheet *xlsxSheet, sst *sharedStringTable) error {
+	cells := make([]CellData, len(sheet.cells))
+	copy(cells, sheet.cells)
+	sortCells(cells)
+
+	fmt.Fprintf(w, `%s<worksheet xmlns="%s" xmlns:r="%s"><sheetData>`, xml.Header, xlsxMainNS, xlsxRelNS)
+	currentRow := int32(-1)
+	for i, c := range cells {
+		if c.RowNumber < 1 || c.ColumnNumber < 1 {
+			continue // No valid position to place the value at
+		}
+		if i > 0 && c.RowNumber == cells[i-1].RowNumber && c.ColumnNumber == cells[i-1].ColumnNumber {
+			continue // Excel rejects duplicate cells, keep the first
+		}
+		if c.RowNumber != currentRow {
+			if currentRow != -1 {
+				w.WriteString(`</row>`)
+			}
+			currentRow = c.RowNumber
+			fmt.Fprintf(w, `<row r="%d">`, currentRow)
+		}
+		ref := FormatRef(c.ColumnNumber, c.RowNumber)
+		if c.SheetValue == "" {
+			fmt.Fprintf(w, `<c r="%s"/>`, ref)
+			continue
+		}
+		t, v := cellXML(c, sst)
+		if t != "" {
+			fmt.Fprintf(w, `<c r="%s" t="%s"><v>`, ref, t)
+		} else {
+			fmt.Fprintf(w, `<c r="%s"><v>`, ref)
+		}
+		xml.EscapeText(w, []byte(v))
+		w.WriteString(`</v></c>`)
+	}
+	if currentRow != -1 {
+		w.WriteString(`</row>`)
+	}
+	w.WriteString(`</sheetData>`)
+
+	if len(sheet.merges) > 0 {
+		fmt.Fprintf(w, `<mergeCells count="%d">`, len(sheet.merges))
+		for _, ref := range sheet.merges {
+			w.WriteString(`<mergeCell ref="`)
+			xml.EscapeText(w, []byte(ref))
+			w.WriteString(`"/>`)
+		}
+		w.WriteString(`</mergeCells>`)
+	}
+	_, err := w.WriteString(`</worksheet>`)
+	return err
+}
+
+// cellXML returns the t attribute and <v> content of a cell, following the type it was
+// read with, so text such as "00123" or "1e3" stays text. Numbers and date serials are
+// numbers when their value is one, as are cells without a type, e.g. read back from
+// Parquet (CellTypeNumber is the zero value), unless leading zeros show it is text.
+func cellXML(d CellData, sst *sharedStringTable) (t, v string) {
+	switch {
+	case d.Type.IsString():
+	case d.Type == CellTypeBool:
+		if b, ok := d.AsBool(); ok {
+			if b {
+				return "b", "1"
+			}
+			return "b", "0"
+		}
+	case d.Type == CellTypeError:
+		return "e", d.SheetValue
+	case isNumericValue(d.SheetValue) && !hasLeadingZero(d.SheetValue):
+		return "", d.SheetValue
+	}
+	return "s", strconv.Itoa(sst.add(d.SheetValue))
+}
+
+// hasLeadingZero reports whether a number is written with a zero before its first
+// significant digit, as in 007 or -012, which Excel never stores for a number
+func hasLeadingZero(value string) bool {
+	value = strings.TrimLeft(value, "+-")
+	return len(value) > 1 && value[0] == '0' && value[1] != '.' && value[1] != 'e' && value[1] != 'E'
+}
+
+// isNumericValue reports whether the value can be stored as an Excel number as-is
+func isNumericValue(value string) bool {
+	// ParseFloat also accepts "NaN", "Inf" and hex floats, which Excel does not
+	if strings.Trim(value, "0123456789+-.eE") != "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+func writeContentTypesXML(w *bufio.Writer, sheetCount int) error {
+	fmt.Fprintf(w, `%s<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`, xml.Header)
+	w.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	w.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	w.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i := 1; i <= sheetCount; i++ {
+		fmt.Fprintf(w, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	w.WriteString(`<Override PartName="/xl/sharedStrings.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sharedStrings+xml"/>`)
+	_, err := w.WriteString(`</Types>`)
+	return err
+}
+
+func writeRootRelsXML(w *bufio.Writer) error {
+	fmt.Fprintf(w, `%s<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`, xml.Header)
+	fmt.Fprintf(w, `<Relationship Id="rId1" Type="%s/officeDocument" Target="xl/workbook.xml"/>`, xlsxRelNS)
+	_, err := w.WriteString(`</Relationships>`)
+	return err
+}
+
+func writeWorkbookXML(w *bufio.Writer, sheets []*xlsxSheet) error {
+	fmt.Fprintf(w, `%s<workbook xmlns="%s" xmlns:r="%s"><sheets>`, xml.Header, xlsxMainNS, xlsxRelNS)
+	for i, sheet := range sheets {
+		w.WriteString(`<sheet name="`)
+		xml.EscapeText(w, []byte(sheet.name))
+		fmt.Fprintf(w, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	_, err := w.WriteString(`</sheets></workbook>`)
+	return err
+}
+
+func writeWorkbookRelsXML(w *bufio.Writer, sheetCount int) error {
+	fmt.Fprintf(w, `%s<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`, xml.Header)
+	for i := 1; i <= sheetCount; i++ {
+		fmt.Fprintf(w, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, i, xlsxRelNS, i)
+	}
+	fmt.Fprintf(w, `<Relationship Id="rId%d" Type="%s/sharedStrings" Target="sharedStrings.xml"/>`, sheetCount+1, xlsxRelNS)
+	_, err := w.WriteString(`</Relationships>`)
+	return err
+}
@@ -0,0 +1,91 @@
+package xlsx
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestXLSXWriterRoundTrip(t *testing.T) {
+	longName := "Quarterly revenue by region and product line"
+	cells := []CellData{
+		{SheetName: "Data", RowNumber: 1, ColumnNumber: 1, SheetValue: "Title", Type: CellTypeSharedString, Merged: true, MergedRange: "A1:B1"},
+		{SheetName: "Data", RowNumber: 1, ColumnNumber: 2, Merged: true, MergedRange: "A1:B1"},
+		{SheetName: "Data", RowNumber: 2, ColumnNumber: 1, SheetValue: "1.5"},
+		{SheetName: "Data", RowNumber: 2, ColumnNumber: 2, SheetValue: "00123", Type: CellTypeSharedString},
+		{SheetName: "Data", RowNumber: 2, ColumnNumber: 3, SheetValue: "TRUE", Type: CellTypeBool},
+		{SheetName: "Data", RowNumber: 2, ColumnNumber: 4, SheetValue: "#N/A", Type: CellTypeError},
+		{SheetName: "Data", RowNumber: 2, ColumnNumber: 5, SheetValue: " padded ", Type: CellTypeInlineString},
+		{SheetName: "Data", RowNumber: 3, ColumnNumber: 1, SheetValue: "007"}, // Untyped, but text
+		{SheetName: longName, RowNumber: 1, ColumnNumber: 1, SheetValue: "long"},
+		{SheetName: "data", RowNumber: 1, ColumnNumber: 1, SheetValue: "duplicate"},
+		{SheetName: "History", RowNumber: 1, ColumnNumber: 1, SheetValue: "reserved"},
+		{SheetName: "a/b", RowNumber: 1, ColumnNumber: 1, SheetValue: "slash"},
+	}
+	path := filepath.Join(t.TempDir(), "out.xlsx")
+	w, err := newXLSXWriter(path, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range cells {
+		if err := w.WriteRow(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+	wantNames := []string{"Data", longName[:maxSheetNameLength], "data (2)", "Sheet4", "a_b"}
+	if got := doc.SheetNames(); !slices.Equal(got, wantNames) {
+		t.Errorf("sheet names = %q, want %q", got, wantNames)
+	}
+	for name, want := range map[string]string{wantNames[1]: "long", "data (2)": "duplicate", "Sheet4": "reserved", "a_b": "slash"} {
+		data, err := doc.ReadSheet(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != 1 || data[0].SheetValue != want {
+			t.Errorf("sheet %s holds %+v, want the cell %q", name, data, want)
+		}
+	}
+
+	data, err := doc.ReadSheet("Data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cellsByRef(data)
+	tests := []struct {
+		ref   string
+		value string
+		typ   CellType
+	}{
+		{"A1", "Title", CellTypeSharedString},
+		{"A2", "1.5", CellTypeNumber},
+		{"B2", "00123", CellTypeSharedString},
+		{"C2", "1", CellTypeBool},
+		{"D2", "#N/A", CellTypeError},
+		{"E2", " padded ", CellTypeSharedString},
+		{"A3", "007", CellTypeSharedString},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if d := got[tt.ref]; d.SheetValue != tt.value || d.Type != tt.typ {
+				t.Errorf("%s = %q (type %d), want %q (type %d)", tt.ref, d.SheetValue, d.Type, tt.value, tt.typ)
+			}
+		})
+	}
+	for _, ref := range []string{"A1", "B1"} {
+		if d := got[ref]; !d.Merged || d.MergedRange != "A1:B1" {
+			t.Errorf("%s: merged %v, range %q, want merged in A1:B1", ref, d.Merged, d.MergedRange)
+		}
+	}
+	if d := got["A2"]; d.Merged {
+		t.Errorf("A2 is flagged merged in %q", d.MergedRange)
+	}
+}